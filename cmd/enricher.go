@@ -0,0 +1,58 @@
+package main
+
+import (
+	"EfectiveMobile/internal/config"
+	"EfectiveMobile/internal/enrichers"
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+// buildEnricher собирает цепочки провайдеров по конфигурации. Выключенные провайдеры
+// пропускаются с предупреждением, а провайдер, который не определяет атрибут своей цепочки,
+// считается ошибкой конфигурации
+func buildEnricher(cfg *config.Config, registry *enrichers.Registry, log *slog.Logger) (*enrichers.Set, error) {
+	mode := cfg.Providers.Local.Mode
+	if mode != enrichers.LocalPrimary && mode != enrichers.LocalFallback {
+		return nil, fmt.Errorf("unknown local provider mode: %s", mode)
+	}
+
+	// first и last - встроенные провайдеры, которые опрашиваются до и после HTTP и локального
+	chain := func(attribute string, first, last []string, httpProvider string, c config.Chain) (enrichers.ChainSettings, error) {
+		names := c.Providers
+		if len(names) == 0 {
+			names = slices.Concat(first, []string{httpProvider, enrichers.ProviderLocal}, last)
+			if mode == enrichers.LocalPrimary {
+				names = slices.Concat(first, []string{enrichers.ProviderLocal, httpProvider}, last)
+			}
+		}
+
+		settings := enrichers.ChainSettings{MinConfidence: c.MinConfidence}
+		for _, name := range names {
+			switch {
+			case registry.Provides(attribute, name):
+				settings.Providers = append(settings.Providers, name)
+			case registry.Has(name):
+				return settings, fmt.Errorf("provider %s in %s chain cannot determine %s", name, attribute, attribute)
+			default:
+				log.Warn("Enrichment provider is disabled", slog.String("attribute", attribute), slog.String("provider", name))
+			}
+		}
+		log.Info("Enrichment chain configured", slog.String("attribute", attribute), slog.Any("providers", settings.Providers), slog.Float64("min confidence", c.MinConfidence))
+		return settings, nil
+	}
+
+	age, err := chain("age", nil, nil, enrichers.ProviderAgify, cfg.Chains.Age)
+	if err != nil {
+		return nil, err
+	}
+	gender, err := chain("gender", []string{enrichers.ProviderPatronymic}, []string{enrichers.ProviderSurname}, enrichers.ProviderGenderize, cfg.Chains.Gender)
+	if err != nil {
+		return nil, err
+	}
+	nationality, err := chain("nationality", nil, nil, enrichers.ProviderNationalize, cfg.Chains.Nationality)
+	if err != nil {
+		return nil, err
+	}
+	return registry.BuildChains(age, gender, nationality)
+}
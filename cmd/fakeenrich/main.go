@@ -0,0 +1,41 @@
+// fakeenrich - локальный сервер, отвечающий как agify, genderize и nationalize.
+// Чтобы сервис работал с ним без интернета, укажите адреса провайдеров:
+//
+//	go run ./cmd/fakeenrich -addr :8090 -latency 100ms -null-rate 0.1
+//	AGIFY_URL=http://localhost:8090/agify/ \
+//	GENDERIZE_URL=http://localhost:8090/genderize/ \
+//	NATIONALIZE_URL=http://localhost:8090/nationalize/ go run .
+package main
+
+import (
+	"EfectiveMobile/pkg/fakeenrich"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+)
+
+func main() {
+	addr := flag.String("addr", ":8090", "listen address")
+	opts := fakeenrich.Options{}
+	flag.DurationVar(&opts.Latency, "latency", 0, "delay before every response")
+	flag.Float64Var(&opts.ErrorRate, "error-rate", 0, "share of requests answered with 500")
+	flag.Float64Var(&opts.RateLimitRate, "rate-limit-rate", 0, "share of requests answered with 429")
+	flag.DurationVar(&opts.RetryAfter, "retry-after", 0, "Retry-After of 429 responses")
+	flag.Float64Var(&opts.NullRate, "null-rate", 0, "share of names without age, gender and nationality")
+	nullNames := flag.String("null-names", "", "comma separated names without age, gender and nationality")
+	flag.IntVar(&opts.Quota, "quota", 0, "daily request limit of every API, 0 - unlimited")
+	flag.Parse()
+
+	if *nullNames != "" {
+		opts.NullNames = strings.Split(*nullNames, ",")
+	}
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	log.Info("Starting fake enrichment server...", slog.String("Address", *addr), slog.Any("options", opts))
+	if err := http.ListenAndServe(*addr, fakeenrich.NewHandler(opts)); err != nil {
+		log.Error("Crashed server", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+}
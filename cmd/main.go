@@ -3,16 +3,21 @@ package main
 import (
 	"EfectiveMobile/internal/config"
 	"EfectiveMobile/internal/db"
+	"EfectiveMobile/internal/enrichers"
 	"EfectiveMobile/internal/handlers"
 	"EfectiveMobile/internal/repositories"
 	"EfectiveMobile/internal/services"
 	"EfectiveMobile/pkg/logger"
+	"EfectiveMobile/pkg/translit"
 	"context"
 	"fmt"
 	"log"
 	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -55,16 +60,98 @@ func main() {
 		log.Error("Failed connect to db", slog.String("error", err.Error()))
 		panic(err)
 	}
-	defer conn.Close(context.Background())
+	defer conn.Close()
 	log.Info("Successfully connect to db")
 
 	router := chi.NewRouter()
 
+	registry := enrichers.NewDefaultRegistry(
+		http.DefaultClient,
+		enrichers.RetryPolicy{MaxAttempts: cfg.Retry.MaxAttempts, BaseDelay: cfg.Retry.BaseDelay, MaxDelay: cfg.Retry.MaxDelay},
+		enrichers.BreakerSettings{Threshold: cfg.Breaker.Threshold, OpenDuration: cfg.Breaker.OpenDuration},
+		map[string]enrichers.ProviderSettings{
+			enrichers.ProviderAgify:       providerSettings(cfg.Providers.Agify),
+			enrichers.ProviderGenderize:   providerSettings(cfg.Providers.Genderize),
+			enrichers.ProviderNationalize: providerSettings(cfg.Providers.Nationalize),
+		},
+	)
+
+	var local *enrichers.Local
+	if cfg.Providers.Local.Enabled {
+		local, err = enrichers.NewLocal(cfg.Providers.Local.Files)
+		if err != nil {
+			log.Error("Failed to load local enrichment data", slog.String("error", err.Error()))
+			panic(err)
+		}
+		registry.Register(enrichers.ProviderLocal, local)
+		go reloadOnSignal(local, log)
+	}
+	enricher, err := buildEnricher(cfg, registry, log)
+	if err != nil {
+		log.Error("Failed to build enricher", slog.String("error", err.Error()))
+		panic(err)
+	}
+
+	partialPolicy, err := services.ParsePartialPolicy(cfg.PartialPolicy)
+	if err != nil {
+		log.Error("Invalid enrichment config", slog.String("error", err.Error()))
+		panic(err)
+	}
+	quotaMode, err := services.ParseQuotaMode(cfg.QuotaMode)
+	if err != nil {
+		log.Error("Invalid enrichment config", slog.String("error", err.Error()))
+		panic(err)
+	}
+	translitStandard, err := translit.Parse(cfg.Transliteration)
+	if err != nil {
+		log.Error("Invalid enrichment config", slog.String("error", err.Error()))
+		panic(err)
+	}
+
+	cr := &repositories.EnrichmentCacheRepo{DB: conn, Log: log}
+	cache := services.NewEnrichmentCache(cr, log, cfg.Cache.Size, cfg.Cache.TTL)
+
+	jr := &repositories.EnrichmentJobRepo{DB: conn, Log: log}
+	lr := &repositories.EnrichmentLogRepo{DB: conn, Log: log}
+
 	pr := &repositories.PersonRepo{DB: conn, Log: log}
-	ps := &services.PersonService{PersonRepo: pr, Log: log}
+	ps := &services.PersonService{
+		PersonRepo:          pr,
+		Enricher:            enricher,
+		Cache:               cache,
+		Jobs:                jr,
+		EnrichmentLog:       lr,
+		Log:                 log,
+		Async:               cfg.Async,
+		LookupTimeout:       cfg.LookupTimeout,
+		PartialPolicy:       partialPolicy,
+		QuotaMode:           quotaMode,
+		RetryDelay:          cfg.RetryDelay,
+		RetryAttempts:       cfg.RetryAttempts,
+		Translit:            translitStandard,
+		CountryHint:         cfg.CountryHint,
+		GenderMinConfidence: cfg.Chains.Gender.MinConfidence,
+	}
 	ph := handlers.PersonHandler{PersonService: ps, Log: log}
 
+	if len(os.Args) > 1 && os.Args[1] == reenrichCommand {
+		if err := runReenrich(ps, os.Args[2:]); err != nil {
+			log.Error("Reenrichment failed", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		return
+	}
+
+	workers := &services.EnrichmentWorkers{PersonService: ps, Jobs: jr, Log: log, Workers: cfg.Workers, PollInterval: cfg.PollInterval}
+	workers.Run(context.Background())
+
+	cleaner := &services.EnrichmentLogCleaner{Repo: lr, Log: log, Retention: cfg.LogRetention}
+	cleaner.Run(context.Background())
+
+	ah := handlers.AdminHandler{PersonService: ps, Cache: cache, Registry: registry, Local: local, Log: log}
+
 	ph.Register(router)
+	ah.Register(router)
 
 	log.Info("Starting server...", slog.String("Address", fmt.Sprintf("%s:%s", cfg.ServerHost, cfg.ServerPort)))
 	err = http.ListenAndServe(fmt.Sprintf("%s:%s", cfg.ServerHost, cfg.ServerPort), router)
@@ -74,3 +161,28 @@ func main() {
 	}
 
 }
+
+func providerSettings(p config.Provider) enrichers.ProviderSettings {
+	return enrichers.ProviderSettings{
+		Enabled:      p.IsEnabled(),
+		URL:          p.URL,
+		APIKey:       p.APIKey,
+		Timeout:      p.Timeout,
+		CountryID:    p.CountryID,
+		QuotaReserve: p.QuotaReserve,
+	}
+}
+
+// reloadOnSignal перечитывает файлы локального провайдера по SIGHUP
+func reloadOnSignal(local *enrichers.Local, log *slog.Logger) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	for range signals {
+		names, err := local.Reload()
+		if err != nil {
+			log.Error("Failed to reload local enrichment data", slog.String("error", err.Error()))
+			continue
+		}
+		log.Info("Local enrichment data reloaded", slog.Int("names", names))
+	}
+}
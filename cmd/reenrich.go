@@ -0,0 +1,45 @@
+package main
+
+import (
+	"EfectiveMobile/internal/handlers"
+	"EfectiveMobile/internal/services"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+)
+
+const reenrichCommand = "reenrich"
+
+// runReenrich выполняет повторное обогащение из командной строки:
+//
+//	go run . reenrich -dry-run -filter "age=isnull&limit=100"
+//
+// Фильтр задаётся в том же формате, что и параметры запроса /api/v1/person/get
+func runReenrich(ps *services.PersonService, args []string) error {
+	fs := flag.NewFlagSet(reenrichCommand, flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "only report changes, do not save them")
+	filter := fs.String("filter", "", "person filter in query string format, e.g. \"gender=isnull&limit=100\"")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	queryParams, err := url.ParseQuery(*filter)
+	if err != nil {
+		return fmt.Errorf("invalid filter: %w", err)
+	}
+	filters, err := handlers.ParseFilters(queryParams)
+	if err != nil {
+		return err
+	}
+
+	report, err := ps.Reenrich(filters, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
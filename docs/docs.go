@@ -15,9 +15,227 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/api/v1/admin/cache": {
+            "delete": {
+                "description": "Удаляет все записи кэша обогащения",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Очистка кэша обогащения",
+                "responses": {
+                    "204": {
+                        "description": "Cache successfully invalidated",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to invalidate cache",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/cache/stats": {
+            "get": {
+                "description": "Возвращает количество попаданий и промахов кэша обогащения",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Статистика кэша обогащения",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.EnrichmentCacheStats"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/cache/{name}": {
+            "delete": {
+                "description": "Удаляет из кэша обогащения записи для указанного имени во всех странах.\nИмя можно передать кириллицей или латиницей",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Удаление записи кэша обогащения",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Cache entry successfully invalidated",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to invalidate cache entry",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/local/reload": {
+            "post": {
+                "description": "Перечитывает CSV- и JSON-файлы локального провайдера (то же делает SIGHUP).\nПри ошибке остаются ранее загруженные данные. Уже закэшированные результаты\nне меняются до очистки кэша",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Перезагрузка локальных данных обогащения",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.LocalReloadResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Local provider is disabled",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to reload local data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/quota": {
+            "get": {
+                "description": "Возвращает остаток дневной квоты каждого провайдера по последнему ответу",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Квоты провайдеров обогащения",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/enrichers.Quota"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/reenrich": {
+            "post": {
+                "description": "Повторно запрашивает возраст, пол и национальность у провайдеров (минуя кэш)\nдля людей, выбранных по тем же фильтрам, что и /api/v1/person/get,\nи возвращает изменившиеся поля. При dry_run=true изменения не сохраняются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Повторное обогащение",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Только показать изменения, не сохраняя их",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя пользователя",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фамилия пользователя",
+                        "name": "surname",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Отчество пользователя",
+                        "name": "patronymic",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Пол пользователя",
+                        "name": "gender",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Национальность пользователя",
+                        "name": "nationality",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Возраст пользователя",
+                        "name": "age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Лимит записей",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение записей",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReenrichReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to reenrich persons",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/person/create": {
             "post": {
-                "description": "Создает нового пользователя с переданными данными",
+                "description": "Создает нового пользователя с переданными данными\nЕсли включено асинхронное обогащение, пользователь сохраняется сразу со статусом\nобогащения pending, а данные запрашиваются в фоне. Если фоновую задачу не удалось\nпоставить в очередь, пользователь всё равно создан и возвращается его ID со статусом failed",
                 "consumes": [
                     "application/json"
                 ],
@@ -41,9 +259,15 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "201": {
-                        "description": "ID нового пользователя",
+                        "description": "ID нового пользователя и результат обогащения",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreatePersonResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "ID нового пользователя, обогащение выполняется в фоне",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/dto.CreatePersonResponse"
                         }
                     },
                     "400": {
@@ -57,6 +281,58 @@ const docTemplate = `{
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "503": {
+                        "description": "Provider quota is exhausted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/person/create/batch": {
+            "post": {
+                "description": "Создает нескольких пользователей; одинаковые имена обогащаются одним запросом,\nостальные группируются в пакетные запросы к провайдерам.\nРезультат для каждого пользователя возвращается на той же позиции, что и в запросе.\nВ одном запросе можно создать не больше 100 пользователей",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "person"
+                ],
+                "summary": "Пакетное создание пользователей",
+                "parameters": [
+                    {
+                        "description": "Данные пользователей для создания (не больше 100)",
+                        "name": "persons",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.CreatePerson"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ID новых пользователей и результаты обогащения",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.CreatePersonResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
@@ -74,17 +350,197 @@ const docTemplate = `{
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID пользователя",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "User successfully deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to delete person",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/person/get": {
+            "get": {
+                "description": "Возвращает отфильтрованные данные о людях\nОператоры для фильтрации значений (не распространяется на limit и offset):\n- ` + "`" + `var=is:X` + "`" + ` — значение равно X\n- ` + "`" + `var=isnt:X` + "`" + ` — значение не равно X\n- ` + "`" + `var=ls:X` + "`" + ` — значение меньше X (только для числовых полей)\n- ` + "`" + `var=mt:X` + "`" + ` — значение больше X (только для числовых полей)\n- ` + "`" + `var=lte:X` + "`" + `, ` + "`" + `var=gte:X` + "`" + ` — значение не больше (не меньше) X (только для числовых полей)\n- ` + "`" + `var=between:X,Y` + "`" + ` — значение от X до Y включительно (только для числовых полей)\n- ` + "`" + `var=in:X,Y,Z` + "`" + `, ` + "`" + `var=nin:X,Y,Z` + "`" + ` — значение входит (не входит) в список (для строковых полей и age)\n- ` + "`" + `var=prefix:X` + "`" + ` — значение начинается с X (только для строковых полей)\n- ` + "`" + `var=contains:X` + "`" + ` — значение содержит X (только для строковых полей)\n- ` + "`" + `var=ilike:X` + "`" + ` — значение равно X без учёта регистра (только для строковых полей)\n- ` + "`" + `var=isnull` + "`" + ` — значение неизвестно (для patronymic, age, gender, nationality)\n- ` + "`" + `var=notnull` + "`" + ` — значение известно (для patronymic, age, gender, nationality)\n- ` + "`" + `nationality=any:X` + "`" + ` — X совпадает с основной национальностью или любым из кандидатов\n- ` + "`" + `name_translit=is:X` + "`" + ` — поиск по транслитерации без учёта регистра; X можно передать и кириллицей\n- Параметр можно повторить, условия объединяются через AND: ` + "`" + `age=mt:20\u0026age=ls:40` + "`" + `\n- Альтернативы через ` + "`" + `|` + "`" + ` объединяются через OR: ` + "`" + `nationality=is:RU|is:UA` + "`" + `\n- ` + "`" + `or=var=op:X|var2=op:Y` + "`" + ` — группа условий на разные поля, объединяемых через OR: ` + "`" + `or=gender=is:female|age=ls:30` + "`" + `\n- Оператор отделяется от значения первым двоеточием, символы ` + "`" + `%` + "`" + ` и ` + "`" + `_` + "`" + ` в значении не являются шаблонами\n- ` + "`" + `|` + "`" + ` и ` + "`" + `,` + "`" + ` внутри значения экранируются обратной косой чертой, ` + "`" + `\\\\` + "`" + ` - сама черта: ` + "`" + `name=is:A\\|B` + "`" + `, ` + "`" + `surname=in:A\\,B,C` + "`" + `\n- Пример:\n- ` + "`" + `age=mt:X` + "`" + ` — значение больше X\n- ` + "`" + `name=is:X` + "`" + ` — значение равно X",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "person"
+                ],
+                "summary": "Получение отфильтрованной информации о людях",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя пользователя",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фамилия пользователя",
+                        "name": "surname",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Отчество пользователя",
+                        "name": "patronymic",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Транслитерация имени",
+                        "name": "name_translit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Транслитерация фамилии",
+                        "name": "surname_translit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Транслитерация отчества",
+                        "name": "patronymic_translit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Пол пользователя",
+                        "name": "gender",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Национальность пользователя",
+                        "name": "nationality",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Возраст пользователя",
+                        "name": "age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Размер выборки, по которой определён возраст",
+                        "name": "age_count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Вероятность пола (например, ` + "`" + `mt:0.9` + "`" + `)",
+                        "name": "gender_probability",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Размер выборки, по которой определён пол",
+                        "name": "gender_count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Вероятность национальности",
+                        "name": "nationality_probability",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Группа условий, объединяемых через OR",
+                        "name": "or",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сортировка: поля через запятую с направлением asc или desc, например ` + "`" + `age:desc,surname:asc` + "`" + `; при равенстве - по id",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Лимит записей (если не задан - выводятся все подходящие данные)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение записей",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Person"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to get persons",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/person/get/{id}": {
+            "get": {
+                "description": "Возвращает данные о человеке по его идентификатору",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "person"
+                ],
+                "summary": "Получение информации о человеке по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID человека",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "User successfully deleted",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.Person"
                         }
                     },
                     "400": {
@@ -94,7 +550,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Failed to delete person",
+                        "description": "Failed to get person",
                         "schema": {
                             "type": "string"
                         }
@@ -102,56 +558,27 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/person/get": {
+        "/api/v1/person/get/{id}/enrichment": {
             "get": {
-                "description": "Возвращает отфильтрованные данные о людях\nОператоры для фильтрации значений (не распространяется на limit и offset):\n- ` + "`" + `var=is:X` + "`" + ` — значение равно X\n- ` + "`" + `var=isnt:X` + "`" + ` — значение не равно X\n- ` + "`" + `var=ls:X` + "`" + ` — значение меньше X (только для age)\n- ` + "`" + `var=mt:X` + "`" + ` — значение больше X (только для age)\n- Пример:\n- ` + "`" + `age=mt:X` + "`" + ` — значение больше X\n- ` + "`" + `name=is:X` + "`" + ` — значение равно X",
+                "description": "Возвращает сохранённые запросы к провайдерам обогащения и их ответы\n(адрес без API-ключа, статус, тело, время ответа), новые записи первыми",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "person"
                 ],
-                "summary": "Получение отфильтрованной информации о людях",
+                "summary": "История обогащения человека",
                 "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Имя пользователя",
-                        "name": "name",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Фамилия пользователя",
-                        "name": "surname",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Отчество пользователя",
-                        "name": "patronymic",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Пол пользователя",
-                        "name": "gender",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Национальность пользователя",
-                        "name": "nationality",
-                        "in": "query"
-                    },
                     {
                         "type": "integer",
-                        "description": "Возраст пользователя",
-                        "name": "age",
-                        "in": "query"
+                        "description": "ID человека",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "Лимит записей (если не задан - выводятся все подходящие данные)",
+                        "description": "Лимит записей",
                         "name": "limit",
                         "in": "query"
                     },
@@ -168,7 +595,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Person"
+                                "$ref": "#/definitions/models.EnrichmentLogEntry"
                             }
                         }
                     },
@@ -179,7 +606,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Failed to get persons",
+                        "description": "Failed to get enrichment log",
                         "schema": {
                             "type": "string"
                         }
@@ -187,40 +614,48 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/person/get/{id}": {
-            "get": {
-                "description": "Возвращает данные о человеке по его идентификатору",
+        "/api/v1/person/search": {
+            "post": {
+                "description": "Ищет людей по JSON-документу с деревом условий. Узел дерева содержит ровно одно из:\n` + "`" + `and` + "`" + ` или ` + "`" + `or` + "`" + ` (массив узлов), ` + "`" + `not` + "`" + ` (узел) или условие ` + "`" + `field` + "`" + `, ` + "`" + `operator` + "`" + `, ` + "`" + `value` + "`" + `.\nПоля и операторы те же, что у /api/v1/person/get; для in, nin и between value - массив.\nСортировка: поля id, name, surname, patronymic, age, age_count, gender, gender_probability,\ngender_count, nationality, nationality_probability; order - asc или desc; при равенстве - по id\n- Пример: ` + "`" + `{\"filter\": {\"or\": [{\"field\": \"nationality\", \"operator\": \"is\", \"value\": \"RU\"}, {\"field\": \"age\", \"operator\": \"between\", \"value\": [20, 30]}]}, \"sort\": [{\"field\": \"age\", \"order\": \"desc\"}], \"limit\": 10}` + "`" + `",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "person"
                 ],
-                "summary": "Получение информации о человеке по ID",
+                "summary": "Поиск людей",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID человека",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Условия поиска",
+                        "name": "search",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SearchPersons"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Person"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Person"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid ID",
+                        "description": "Invalid search request",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "500": {
-                        "description": "Failed to get person",
+                        "description": "Failed to search persons",
                         "schema": {
                             "type": "string"
                         }
@@ -230,7 +665,7 @@ const docTemplate = `{
         },
         "/api/v1/person/update": {
             "put": {
-                "description": "Обновляет данные пользователя с переданными новыми данными",
+                "description": "Обновляет данные пользователя с переданными новыми данными\nПереданные age, gender и nationality отмечаются в provenance как manual, и обогащение их больше не перезаписывает.\nПоля из reset снова отдаются обогащению и запрашиваются у провайдеров в фоне",
                 "consumes": [
                     "application/json"
                 ],
@@ -283,6 +718,10 @@ const docTemplate = `{
                 "surname"
             ],
             "properties": {
+                "country_hint": {
+                    "description": "CountryHint - код страны ISO 3166-1 alpha-2, уточняющий запросы к agify и genderize",
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 },
@@ -294,6 +733,61 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.CreatePersonResponse": {
+            "type": "object",
+            "properties": {
+                "enrichment": {
+                    "$ref": "#/definitions/dto.EnrichmentReport"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.EnrichmentReport": {
+            "type": "object",
+            "properties": {
+                "degraded": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "missing": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "retry_scheduled": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.FieldChange": {
+            "type": "object",
+            "properties": {
+                "new": {},
+                "old": {}
+            }
+        },
+        "dto.LocalReloadResponse": {
+            "type": "object",
+            "properties": {
+                "names": {
+                    "type": "integer"
+                }
+            }
+        },
         "dto.PersonUpdate": {
             "type": "object",
             "properties": {
@@ -315,34 +809,302 @@ const docTemplate = `{
                 "patronymic": {
                     "type": "string"
                 },
+                "reset": {
+                    "description": "Reset - поля (age, gender, nationality), которые снова должны определяться обогащением",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "surname": {
                     "type": "string"
                 }
             }
         },
+        "dto.ReenrichPerson": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/dto.FieldChange"
+                    }
+                },
+                "errors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.ReenrichReport": {
+            "type": "object",
+            "properties": {
+                "changed": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "persons": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ReenrichPerson"
+                    }
+                },
+                "processed": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.SearchFilter": {
+            "type": "object",
+            "properties": {
+                "and": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.SearchFilter"
+                    }
+                },
+                "field": {
+                    "type": "string",
+                    "example": "age"
+                },
+                "not": {
+                    "$ref": "#/definitions/dto.SearchFilter"
+                },
+                "operator": {
+                    "type": "string",
+                    "example": "between"
+                },
+                "or": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.SearchFilter"
+                    }
+                },
+                "value": {
+                    "type": "object"
+                }
+            }
+        },
+        "dto.SearchPersons": {
+            "type": "object",
+            "properties": {
+                "filter": {
+                    "$ref": "#/definitions/dto.SearchFilter"
+                },
+                "limit": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "offset": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "sort": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "$ref": "#/definitions/dto.SearchSort"
+                    }
+                }
+            }
+        },
+        "dto.SearchSort": {
+            "type": "object",
+            "required": [
+                "field"
+            ],
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "age"
+                },
+                "order": {
+                    "description": "Order - asc (по умолчанию) или desc",
+                    "type": "string",
+                    "enum": [
+                        "asc",
+                        "desc"
+                    ],
+                    "example": "desc"
+                }
+            }
+        },
+        "enrichers.Quota": {
+            "type": "object",
+            "properties": {
+                "known": {
+                    "type": "boolean"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "remaining": {
+                    "type": "integer"
+                },
+                "reserve": {
+                    "type": "integer"
+                },
+                "reset_at": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.EnrichmentCacheStats": {
+            "type": "object",
+            "properties": {
+                "db_hits": {
+                    "type": "integer"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "memory_hits": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.EnrichmentLogEntry": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "person_id": {
+                    "type": "integer"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FieldProvenance": {
+            "type": "object",
+            "properties": {
+                "provider": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
         "models.Person": {
             "type": "object",
             "properties": {
                 "age": {
                     "type": "integer"
                 },
+                "age_count": {
+                    "type": "integer"
+                },
+                "country_hint": {
+                    "type": "string"
+                },
+                "enrichment_status": {
+                    "type": "string"
+                },
                 "gender": {
                     "type": "string"
                 },
+                "gender_count": {
+                    "type": "integer"
+                },
+                "gender_probability": {
+                    "type": "number"
+                },
                 "id": {
                     "type": "integer"
                 },
                 "name": {
                     "type": "string"
                 },
+                "name_translit": {
+                    "type": "string"
+                },
+                "nationalities": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PersonNationality"
+                    }
+                },
                 "nationality": {
                     "type": "string"
                 },
+                "nationality_probability": {
+                    "type": "number"
+                },
                 "patronymic": {
                     "type": "string"
                 },
+                "patronymic_translit": {
+                    "type": "string"
+                },
+                "provenance": {
+                    "description": "Provenance - происхождение значений age, gender и nationality по имени поля",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.FieldProvenance"
+                    }
+                },
                 "surname": {
                     "type": "string"
+                },
+                "surname_translit": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PersonNationality": {
+            "type": "object",
+            "properties": {
+                "country_id": {
+                    "type": "string"
+                },
+                "probability": {
+                    "type": "number"
+                },
+                "rank": {
+                    "type": "integer"
                 }
             }
         }
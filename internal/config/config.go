@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
@@ -12,6 +13,7 @@ type Config struct {
 	Env        string `yaml:"env" env-required:"true"`
 	Database   `yaml:"database"`
 	HttpServer `yaml:"httpServer"`
+	Enrichment `yaml:"enrichment"`
 }
 
 type Database struct {
@@ -27,6 +29,92 @@ type HttpServer struct {
 	ServerPort string `yaml:"port"`
 }
 
+type Enrichment struct {
+	LookupTimeout time.Duration `yaml:"lookupTimeout" env-default:"5s"`
+	PartialPolicy string        `yaml:"partialPolicy" env-default:"fail"`
+	QuotaMode     string        `yaml:"quotaMode" env-default:"queue"`
+	RetryDelay    time.Duration `yaml:"retryDelay" env-default:"1m"`
+	RetryAttempts int           `yaml:"retryAttempts" env-default:"3"`
+	Async         bool          `yaml:"async"`
+	Workers       int           `yaml:"workers" env-default:"4"`
+	PollInterval  time.Duration `yaml:"pollInterval" env-default:"1s"`
+	Cache         Cache         `yaml:"cache"`
+	Retry         Retry         `yaml:"retry"`
+	Breaker       Breaker       `yaml:"breaker"`
+	Providers     Providers     `yaml:"providers"`
+	Chains        Chains        `yaml:"chains" env-prefix:"CHAINS_"`
+	// LogRetention - сколько хранится журнал запросов к провайдерам; 0 - бессрочно
+	LogRetention time.Duration `yaml:"logRetention" env-default:"720h"`
+	// CountryHint - страна ISO 3166-1 alpha-2 по умолчанию для людей, созданных без country_hint
+	CountryHint string `yaml:"countryHint"`
+	// Transliteration - стандарт транслитерации имён для запросов к провайдерам: gost779 или icao
+	Transliteration string `yaml:"transliteration" env-default:"icao"`
+}
+
+type Cache struct {
+	Size int           `yaml:"size" env-default:"10000"`
+	TTL  time.Duration `yaml:"ttl" env-default:"720h"`
+}
+
+type Retry struct {
+	MaxAttempts int           `yaml:"maxAttempts" env-default:"3"`
+	BaseDelay   time.Duration `yaml:"baseDelay" env-default:"200ms"`
+	MaxDelay    time.Duration `yaml:"maxDelay" env-default:"2s"`
+}
+
+type Breaker struct {
+	Threshold    int           `yaml:"threshold" env-default:"5"`
+	OpenDuration time.Duration `yaml:"openDuration" env-default:"30s"`
+}
+
+// Chains - порядок опроса провайдеров для каждого атрибута. Если цепочка не задана,
+// используется HTTP-провайдер атрибута и локальный провайдер в режиме providers.local.mode,
+// а пол сначала определяется по отчеству и, если остальные провайдеры не справились, по фамилии
+type Chains struct {
+	Age         Chain `yaml:"age" env-prefix:"AGE_"`
+	Gender      Chain `yaml:"gender" env-prefix:"GENDER_"`
+	Nationality Chain `yaml:"nationality" env-prefix:"NATIONALITY_"`
+}
+
+// Chain - цепочка провайдеров одного атрибута. Ответ принимается, если его уверенность не ниже
+// MinConfidence: вероятность для пола и национальности, размер выборки для возраста
+type Chain struct {
+	Providers     []string `yaml:"providers" env:"PROVIDERS" env-separator:","`
+	MinConfidence float64  `yaml:"minConfidence" env:"MIN_CONFIDENCE"`
+}
+
+type Providers struct {
+	Agify       Provider `yaml:"agify" env-prefix:"AGIFY_"`
+	Genderize   Provider `yaml:"genderize" env-prefix:"GENDERIZE_"`
+	Nationalize Provider `yaml:"nationalize" env-prefix:"NATIONALIZE_"`
+	Local       Local    `yaml:"local" env-prefix:"LOCAL_"`
+}
+
+// Local - настройки локального провайдера, который берёт статистику имён из CSV- или JSON-файлов.
+// Mode primary - локальные данные запрашиваются до HTTP-провайдеров, fallback - только когда
+// HTTP-провайдеры не ответили или не определили значение
+type Local struct {
+	Enabled bool     `yaml:"enabled" env:"ENABLED"`
+	Files   []string `yaml:"files" env:"FILES" env-separator:","`
+	Mode    string   `yaml:"mode" env:"MODE" env-default:"fallback"`
+}
+
+// Provider - настройки HTTP-провайдера. Enabled - указатель, чтобы отличать явное false
+// от отсутствующей настройки (по умолчанию провайдер включён). QuotaReserve - остаток квоты,
+// при котором провайдер перестаёт вызываться до её сброса
+type Provider struct {
+	Enabled      *bool         `yaml:"enabled"`
+	URL          string        `yaml:"url" env:"URL"`
+	APIKey       string        `yaml:"apiKey" env:"API_KEY"`
+	Timeout      time.Duration `yaml:"timeout"`
+	CountryID    string        `yaml:"countryId"`
+	QuotaReserve int           `yaml:"quotaReserve"`
+}
+
+func (p Provider) IsEnabled() bool {
+	return p.Enabled == nil || *p.Enabled
+}
+
 func MustLoad() (*Config, error) {
 	workdir, err := os.Getwd()
 	if err != nil {
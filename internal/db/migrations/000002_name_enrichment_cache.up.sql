@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS name_enrichment_cache(
+    name VARCHAR PRIMARY KEY,
+    data JSONB NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+)
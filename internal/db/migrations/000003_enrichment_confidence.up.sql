@@ -0,0 +1,5 @@
+ALTER TABLE persons
+    ADD COLUMN IF NOT EXISTS age_count INT,
+    ADD COLUMN IF NOT EXISTS gender_probability DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS gender_count INT,
+    ADD COLUMN IF NOT EXISTS nationality_probability DOUBLE PRECISION
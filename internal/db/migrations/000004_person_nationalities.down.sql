@@ -0,0 +1 @@
+DROP TABLE IF EXISTS person_nationalities
\ No newline at end of file
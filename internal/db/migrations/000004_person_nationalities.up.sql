@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS person_nationalities(
+    person_id INT NOT NULL REFERENCES persons(personId) ON DELETE CASCADE,
+    country_id VARCHAR NOT NULL,
+    probability DOUBLE PRECISION NOT NULL,
+    rank INT NOT NULL,
+    PRIMARY KEY (person_id, rank)
+);
+
+CREATE INDEX IF NOT EXISTS person_nationalities_country_id_idx ON person_nationalities(country_id);
+
+INSERT INTO person_nationalities (person_id, country_id, probability, rank)
+SELECT personId, nationality, COALESCE(nationality_probability, 0), 1 FROM persons WHERE nationality <> ''
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,12 @@
+ALTER TABLE persons
+    ALTER COLUMN age DROP NOT NULL,
+    ALTER COLUMN gender DROP NOT NULL,
+    ALTER COLUMN nationality DROP NOT NULL;
+
+-- Раньше неизвестные значения сохранялись как 0 и пустые строки
+UPDATE persons SET age = NULL WHERE age = 0;
+UPDATE persons SET gender = NULL WHERE gender = '';
+UPDATE persons SET nationality = NULL WHERE nationality = '';
+
+-- В кэше неизвестные значения тоже хранились как 0 и пустые строки
+DELETE FROM name_enrichment_cache;
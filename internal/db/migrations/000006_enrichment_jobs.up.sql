@@ -0,0 +1,14 @@
+ALTER TABLE persons ADD COLUMN IF NOT EXISTS enrichment_status VARCHAR NOT NULL DEFAULT 'done';
+
+CREATE TABLE IF NOT EXISTS enrichment_jobs(
+    id SERIAL PRIMARY KEY,
+    person_id INT NOT NULL REFERENCES persons(personId) ON DELETE CASCADE,
+    attributes VARCHAR[] NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    run_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_until TIMESTAMPTZ,
+    last_error VARCHAR,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS enrichment_jobs_run_at_idx ON enrichment_jobs(run_at);
@@ -0,0 +1,8 @@
+ALTER TABLE persons ADD COLUMN IF NOT EXISTS provenance JSONB NOT NULL DEFAULT '{}';
+
+-- Значения, сохранённые до появления provenance, считаются полученными от провайдеров
+UPDATE persons SET provenance = jsonb_strip_nulls(jsonb_build_object(
+    'age', CASE WHEN age IS NOT NULL THEN jsonb_build_object('source', 'enriched', 'updated_at', now()) END,
+    'gender', CASE WHEN gender IS NOT NULL THEN jsonb_build_object('source', 'enriched', 'updated_at', now()) END,
+    'nationality', CASE WHEN nationality IS NOT NULL THEN jsonb_build_object('source', 'enriched', 'updated_at', now()) END
+));
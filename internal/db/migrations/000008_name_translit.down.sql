@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS persons_surname_translit_idx;
+DROP INDEX IF EXISTS persons_name_translit_idx;
+
+ALTER TABLE persons
+    DROP COLUMN IF EXISTS name_translit,
+    DROP COLUMN IF EXISTS surname_translit,
+    DROP COLUMN IF EXISTS patronymic_translit;
@@ -0,0 +1,10 @@
+ALTER TABLE persons
+    ADD COLUMN IF NOT EXISTS name_translit VARCHAR,
+    ADD COLUMN IF NOT EXISTS surname_translit VARCHAR,
+    ADD COLUMN IF NOT EXISTS patronymic_translit VARCHAR;
+
+-- До этой миграции принимались только латинские имена, транслитерация совпадает с оригиналом
+UPDATE persons SET name_translit = name, surname_translit = surname, patronymic_translit = patronymic;
+
+CREATE INDEX IF NOT EXISTS persons_name_translit_idx ON persons(lower(name_translit));
+CREATE INDEX IF NOT EXISTS persons_surname_translit_idx ON persons(lower(surname_translit));
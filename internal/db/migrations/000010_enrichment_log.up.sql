@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS enrichment_log(
+    id BIGSERIAL PRIMARY KEY,
+    person_id INT NOT NULL REFERENCES persons(personId) ON DELETE CASCADE,
+    provider VARCHAR NOT NULL,
+    url VARCHAR NOT NULL,
+    status_code INT,
+    body TEXT,
+    latency_ms INT NOT NULL,
+    error VARCHAR,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS enrichment_log_person_idx ON enrichment_log(person_id, created_at);
+CREATE INDEX IF NOT EXISTS enrichment_log_created_at_idx ON enrichment_log(created_at);
@@ -4,7 +4,7 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type ConnectionInfo struct {
@@ -15,14 +15,20 @@ type ConnectionInfo struct {
 	DBName   string
 }
 
-func CreatePsqlConnection(cfg string) (*pgx.Conn, error) {
+// CreatePsqlConnection возвращает пул соединений: к базе одновременно обращаются
+// обработчики запросов и фоновые воркеры обогащения
+func CreatePsqlConnection(cfg string) (*pgxpool.Pool, error) {
 
-	conn, err := pgx.Connect(context.Background(), cfg)
+	pool, err := pgxpool.New(context.Background(), cfg)
 	if err != nil {
 		return nil, err
 	}
+	if err := pool.Ping(context.Background()); err != nil {
+		pool.Close()
+		return nil, err
+	}
 
-	return conn, nil
+	return pool, nil
 }
 
 func MakeConnectionURL(info ConnectionInfo) string {
@@ -4,4 +4,6 @@ type CreatePerson struct {
 	Name       string `json:"name" validate:"required"`
 	Surname    string `json:"surname" validate:"required"`
 	Patronymic string `json:"patronymic,omitempty"`
+	// CountryHint - код страны ISO 3166-1 alpha-2, уточняющий запросы к agify и genderize
+	CountryHint string `json:"country_hint,omitempty" validate:"omitempty,len=2,alpha"`
 }
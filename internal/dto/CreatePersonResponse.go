@@ -0,0 +1,15 @@
+package dto
+
+type CreatePersonResponse struct {
+	ID         int               `json:"id,omitempty"`
+	Enrichment *EnrichmentReport `json:"enrichment,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+type EnrichmentReport struct {
+	Status         string            `json:"status"`
+	Missing        []string          `json:"missing,omitempty"`
+	Errors         map[string]string `json:"errors,omitempty"`
+	RetryScheduled bool              `json:"retry_scheduled,omitempty"`
+	Degraded       bool              `json:"degraded,omitempty"`
+}
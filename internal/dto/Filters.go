@@ -1,12 +1,24 @@
 package dto
 
+// Filters - параметры фильтрации списка людей. Каждый параметр может повторяться;
+// Or - группы условий на разные поля, объединяемых через OR; Sort - поля сортировки
+// вида "age:desc,surname:asc"
 type Filters struct {
-	ByName        string
-	BySurname     string
-	ByPatronymic  string
-	ByAge         string
-	ByGender      string
-	ByNationality string
-	ByLimit       int
-	ByOffset      int
+	ByName                   []string
+	BySurname                []string
+	ByPatronymic             []string
+	ByNameTranslit           []string
+	BySurnameTranslit        []string
+	ByPatronymicTranslit     []string
+	ByAge                    []string
+	ByAgeCount               []string
+	ByGender                 []string
+	ByGenderProbability      []string
+	ByGenderCount            []string
+	ByNationality            []string
+	ByNationalityProbability []string
+	Or                       []string
+	Sort                     []string
+	ByLimit                  int
+	ByOffset                 int
 }
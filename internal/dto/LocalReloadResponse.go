@@ -0,0 +1,5 @@
+package dto
+
+type LocalReloadResponse struct {
+	Names int `json:"names"`
+}
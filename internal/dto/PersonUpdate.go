@@ -8,4 +8,6 @@ type PersonUpdate struct {
 	Age         int    `json:"age,omitempty"`
 	Gender      string `json:"gender,omitempty"`
 	Nationality string `json:"nationality,omitempty"`
+	// Reset - поля (age, gender, nationality), которые снова должны определяться обогащением
+	Reset []string `json:"reset,omitempty"`
 }
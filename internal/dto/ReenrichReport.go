@@ -0,0 +1,20 @@
+package dto
+
+type ReenrichReport struct {
+	DryRun    bool             `json:"dry_run"`
+	Processed int              `json:"processed"`
+	Changed   int              `json:"changed"`
+	Failed    int              `json:"failed"`
+	Persons   []ReenrichPerson `json:"persons"`
+}
+
+type ReenrichPerson struct {
+	ID      int                    `json:"id"`
+	Changes map[string]FieldChange `json:"changes,omitempty"`
+	Errors  map[string]string      `json:"errors,omitempty"`
+}
+
+type FieldChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
@@ -0,0 +1,27 @@
+package dto
+
+// SearchPersons - запрос поиска людей. Filter может быть не задан, тогда выбираются все люди
+type SearchPersons struct {
+	Filter *SearchFilter `json:"filter,omitempty"`
+	Sort   []SearchSort  `json:"sort,omitempty" validate:"max=10,dive"`
+	Limit  int           `json:"limit,omitempty" validate:"gte=0"`
+	Offset int           `json:"offset,omitempty" validate:"gte=0"`
+}
+
+// SearchFilter - узел дерева условий. В узле задаётся ровно одно из: and, or, not
+// или условие field, operator, value. Поля и операторы те же, что у /api/v1/person/get;
+// для in, nin и between value - массив
+type SearchFilter struct {
+	And      []SearchFilter `json:"and,omitempty"`
+	Or       []SearchFilter `json:"or,omitempty"`
+	Not      *SearchFilter  `json:"not,omitempty"`
+	Field    string         `json:"field,omitempty" example:"age"`
+	Operator string         `json:"operator,omitempty" example:"between"`
+	Value    any            `json:"value,omitempty" swaggertype:"object"`
+}
+
+type SearchSort struct {
+	Field string `json:"field" validate:"required" example:"age"`
+	// Order - asc (по умолчанию) или desc
+	Order string `json:"order,omitempty" validate:"omitempty,oneof=asc desc" example:"desc"`
+}
@@ -0,0 +1,46 @@
+package enrichers
+
+import (
+	"context"
+)
+
+const AgifyURL = "https://api.agify.io/"
+
+type Agify struct {
+	HTTP
+}
+
+type agifyResponse struct {
+	Name  string `json:"name"`
+	Age   *int   `json:"age"`
+	Count int    `json:"count"`
+}
+
+func (r agifyResponse) result() *AgeResult {
+	return &AgeResult{Age: r.Age, Count: r.Count, Provider: ProviderAgify}
+}
+
+func (a *Agify) EnrichAge(ctx context.Context, q Query) (*AgeResult, error) {
+	var data agifyResponse
+	if err := a.getJSON(ctx, a.params(q, true), &data); err != nil {
+		return nil, wrapError(ctx, "age", err)
+	}
+	return data.result(), nil
+}
+
+func (a *Agify) EnrichAges(ctx context.Context, qs []Query) ([]*AgeResult, error) {
+	var data []agifyResponse
+	if err := a.getJSON(ctx, a.multiParams(qs, true), &data); err != nil {
+		return nil, wrapError(ctx, "age", err)
+	}
+	matched, err := matchByName(qs, data, func(r agifyResponse) string { return r.Name })
+	if err != nil {
+		return nil, wrapError(ctx, "age", err)
+	}
+
+	results := make([]*AgeResult, len(matched))
+	for i, r := range matched {
+		results[i] = r.result()
+	}
+	return results, nil
+}
@@ -0,0 +1,166 @@
+package enrichers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// MaxBatchSize - максимальное количество имён в одном запросе к agify, genderize и nationalize
+const MaxBatchSize = 10
+
+// MultiAgeEnricher и аналоги реализуют провайдеры, умеющие обрабатывать несколько имён
+// одним запросом. Результаты возвращаются в порядке запросов
+type MultiAgeEnricher interface {
+	EnrichAges(ctx context.Context, qs []Query) ([]*AgeResult, error)
+}
+
+type MultiGenderEnricher interface {
+	EnrichGenders(ctx context.Context, qs []Query) ([]*GenderResult, error)
+}
+
+type MultiNationalityEnricher interface {
+	EnrichNationalities(ctx context.Context, qs []Query) ([]*NationalityResult, error)
+}
+
+// AgeBatcher и аналоги реализуют составные провайдеры (например, AgeFallback), которые
+// сами возвращают результат и ошибку для каждого запроса
+type AgeBatcher interface {
+	EnrichAgeBatch(ctx context.Context, qs []Query) ([]*AgeResult, []error)
+}
+
+type GenderBatcher interface {
+	EnrichGenderBatch(ctx context.Context, qs []Query) ([]*GenderResult, []error)
+}
+
+type NationalityBatcher interface {
+	EnrichNationalityBatch(ctx context.Context, qs []Query) ([]*NationalityResult, []error)
+}
+
+// BatchEnricher обогащает список запросов; результаты и ошибки выровнены по индексам qs
+type BatchEnricher interface {
+	AgeBatcher
+	GenderBatcher
+	NationalityBatcher
+}
+
+func (s *Set) EnrichAgeBatch(ctx context.Context, qs []Query) ([]*AgeResult, []error) {
+	if s.Age == nil {
+		return runBatch[AgeResult](ctx, qs, nil, nil)
+	}
+	return ageBatch(ctx, s.Age, qs)
+}
+
+func (s *Set) EnrichGenderBatch(ctx context.Context, qs []Query) ([]*GenderResult, []error) {
+	if s.Gender == nil {
+		return runBatch[GenderResult](ctx, qs, nil, nil)
+	}
+	return genderBatch(ctx, s.Gender, qs)
+}
+
+func (s *Set) EnrichNationalityBatch(ctx context.Context, qs []Query) ([]*NationalityResult, []error) {
+	if s.Nationality == nil {
+		return runBatch[NationalityResult](ctx, qs, nil, nil)
+	}
+	return nationalityBatch(ctx, s.Nationality, qs)
+}
+
+func ageBatch(ctx context.Context, e AgeEnricher, qs []Query) ([]*AgeResult, []error) {
+	if batcher, ok := e.(AgeBatcher); ok {
+		return batcher.EnrichAgeBatch(ctx, qs)
+	}
+	multi, _ := e.(MultiAgeEnricher)
+	var many func(context.Context, []Query) ([]*AgeResult, error)
+	if multi != nil {
+		many = multi.EnrichAges
+	}
+	return runBatch(ctx, qs, e.EnrichAge, many)
+}
+
+func genderBatch(ctx context.Context, e GenderEnricher, qs []Query) ([]*GenderResult, []error) {
+	if batcher, ok := e.(GenderBatcher); ok {
+		return batcher.EnrichGenderBatch(ctx, qs)
+	}
+	multi, _ := e.(MultiGenderEnricher)
+	var many func(context.Context, []Query) ([]*GenderResult, error)
+	if multi != nil {
+		many = multi.EnrichGenders
+	}
+	return runBatch(ctx, qs, e.EnrichGender, many)
+}
+
+func nationalityBatch(ctx context.Context, e NationalityEnricher, qs []Query) ([]*NationalityResult, []error) {
+	if batcher, ok := e.(NationalityBatcher); ok {
+		return batcher.EnrichNationalityBatch(ctx, qs)
+	}
+	multi, _ := e.(MultiNationalityEnricher)
+	var many func(context.Context, []Query) ([]*NationalityResult, error)
+	if multi != nil {
+		many = multi.EnrichNationalities
+	}
+	return runBatch(ctx, qs, e.EnrichNationality, many)
+}
+
+// runBatch делит qs на пачки по MaxBatchSize и обрабатывает каждую одним запросом, если
+// провайдер это поддерживает (many != nil), иначе запрашивает имена по одному. Страна
+// задаётся на весь запрос, поэтому в пачку попадают только запросы с одной страной
+func runBatch[R any](ctx context.Context, qs []Query, one func(context.Context, Query) (*R, error), many func(context.Context, []Query) ([]*R, error)) ([]*R, []error) {
+	results := make([]*R, len(qs))
+	errs := make([]error, len(qs))
+
+	countries := []string{}
+	byCountry := map[string][]int{}
+	for i, q := range qs {
+		if _, ok := byCountry[q.CountryID]; !ok {
+			countries = append(countries, q.CountryID)
+		}
+		byCountry[q.CountryID] = append(byCountry[q.CountryID], i)
+	}
+
+	for _, country := range countries {
+		for chunk := range slices.Chunk(byCountry[country], MaxBatchSize) {
+			switch {
+			case one == nil:
+				for _, i := range chunk {
+					errs[i] = ErrNotConfigured
+				}
+			case many != nil && len(chunk) > 1:
+				batch := make([]Query, len(chunk))
+				for k, i := range chunk {
+					batch[k] = qs[i]
+				}
+				res, err := many(ctx, batch)
+				for k, i := range chunk {
+					if err != nil {
+						errs[i] = err
+						continue
+					}
+					results[i] = res[k]
+				}
+			default:
+				for _, i := range chunk {
+					results[i], errs[i] = one(ctx, qs[i])
+				}
+			}
+		}
+	}
+	return results, errs
+}
+
+// matchByName сопоставляет элементы ответа с запросами по имени, которое вернул провайдер
+func matchByName[T any](qs []Query, items []T, name func(T) string) ([]T, error) {
+	byName := make(map[string]T, len(items))
+	for _, item := range items {
+		byName[name(item)] = item
+	}
+
+	matched := make([]T, len(qs))
+	for i, q := range qs {
+		item, ok := byName[q.Name]
+		if !ok {
+			return nil, fmt.Errorf("name %q is missing in response", q.Name)
+		}
+		matched[i] = item
+	}
+	return matched, nil
+}
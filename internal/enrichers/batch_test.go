@@ -0,0 +1,133 @@
+package enrichers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"EfectiveMobile/pkg/fakeenrich"
+)
+
+func TestRunBatchChunksByCountry(t *testing.T) {
+	srv := fakeenrich.NewServer(fakeenrich.Options{})
+	defer srv.Close()
+	agify := &Agify{HTTP: newFakeHTTP(ProviderAgify, srv.AgifyURL(), fastRetry, nil)}
+	set := &Set{Age: agify}
+
+	qs := []Query{}
+	for i := range 12 {
+		qs = append(qs, Query{Name: fmt.Sprintf("Ru%02d", i), CountryID: "RU"})
+	}
+	for i := range 11 {
+		qs = append(qs, Query{Name: fmt.Sprintf("Any%02d", i)})
+	}
+	qs = append(qs, Query{Name: "Ru00", CountryID: "RU"}, Query{Name: "Solo", CountryID: "KZ"})
+
+	recorder := &Recorder{}
+	results, errs := set.EnrichAgeBatch(WithRecorder(context.Background(), recorder), qs)
+
+	for i, q := range qs {
+		if errs[i] != nil {
+			t.Fatalf("query %d (%s) error = %v", i, q.Name, errs[i])
+		}
+		single, err := agify.EnrichAge(context.Background(), q)
+		if err != nil {
+			t.Fatalf("EnrichAge(%s): %v", q.Name, err)
+		}
+		if results[i] == nil || results[i].Age == nil || *results[i].Age != *single.Age {
+			t.Errorf("query %d (%s) = %+v; want age %d as in a single request", i, q.Name, results[i], *single.Age)
+		}
+	}
+
+	// RU: 10 + 3 имени (с повтором Ru00), без страны: 10 + 1, KZ: одно имя отдельным запросом
+	if got := len(recorder.exchanges); got != 5 {
+		t.Errorf("requests = %d; want 5", got)
+	}
+	for _, e := range recorder.exchanges {
+		u, err := url.Parse(e.URL)
+		if err != nil {
+			t.Fatalf("recorded URL %q: %v", e.URL, err)
+		}
+		if len(e.Names) > MaxBatchSize {
+			t.Errorf("request has %d names; want at most %d", len(e.Names), MaxBatchSize)
+		}
+		country := u.Query().Get("country_id")
+		for _, name := range e.Names {
+			var want string
+			switch {
+			case strings.HasPrefix(name, "Ru"):
+				want = "RU"
+			case name == "Solo":
+				want = "KZ"
+			}
+			if country != want {
+				t.Errorf("%s sent with country %q; want %q", name, country, want)
+			}
+		}
+	}
+}
+
+func TestEnrichAgesMissingName(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]any{{"name": "Ivan", "age": 40, "count": 10}})
+	}))
+	defer srv.Close()
+	set := &Set{Age: &Agify{HTTP: newFakeHTTP(ProviderAgify, srv.URL, fastRetry, nil)}}
+
+	results, errs := set.EnrichAgeBatch(context.Background(), []Query{{Name: "Ivan"}, {Name: "Petr"}})
+	for i := range errs {
+		if errs[i] == nil || !strings.Contains(errs[i].Error(), `"Petr" is missing`) {
+			t.Errorf("query %d error = %v; want missing Petr", i, errs[i])
+		}
+		if results[i] != nil {
+			t.Errorf("query %d result = %+v; want nil", i, results[i])
+		}
+	}
+}
+
+func TestMatchByName(t *testing.T) {
+	type item struct{ name, value string }
+	name := func(i item) string { return i.name }
+
+	tests := []struct {
+		name    string
+		qs      []string
+		items   []item
+		want    []string
+		wantErr string
+	}{
+		{"reordered", []string{"a", "b"}, []item{{"b", "2"}, {"a", "1"}}, []string{"1", "2"}, ""},
+		{"duplicate query", []string{"a", "a"}, []item{{"a", "1"}, {"a", "1"}}, []string{"1", "1"}, ""},
+		{"extra item", []string{"a"}, []item{{"a", "1"}, {"z", "9"}}, []string{"1"}, ""},
+		{"missing item", []string{"a", "b"}, []item{{"a", "1"}}, nil, `name "b" is missing`},
+		{"case matters", []string{"A"}, []item{{"a", "1"}}, nil, `name "A" is missing`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qs := make([]Query, len(tt.qs))
+			for i, n := range tt.qs {
+				qs[i] = Query{Name: n}
+			}
+			matched, err := matchByName(qs, tt.items, name)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("matchByName error = %v; want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("matchByName: %v", err)
+			}
+			for i, want := range tt.want {
+				if matched[i].value != want {
+					t.Errorf("matched[%d] = %+v; want value %s", i, matched[i], want)
+				}
+			}
+		})
+	}
+}
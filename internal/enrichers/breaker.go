@@ -0,0 +1,67 @@
+package enrichers
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type BreakerSettings struct {
+	Threshold    int
+	OpenDuration time.Duration
+}
+
+// Breaker размыкается после Threshold неудачных запросов подряд и не пропускает
+// запросы к провайдеру в течение OpenDuration, после чего пропускает один пробный запрос
+type Breaker struct {
+	BreakerSettings
+
+	mu          sync.Mutex
+	failures    int
+	openedUntil time.Time
+	probing     bool
+}
+
+func (b *Breaker) Allow() bool {
+	if b == nil || b.Threshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.Threshold {
+		return true
+	}
+	if time.Now().Before(b.openedUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *Breaker) Success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+}
+
+func (b *Breaker) Failure() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.Threshold > 0 && b.failures >= b.Threshold {
+		b.openedUntil = time.Now().Add(b.OpenDuration)
+	}
+}
@@ -0,0 +1,54 @@
+package enrichers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	b := &Breaker{BreakerSettings: BreakerSettings{Threshold: 2, OpenDuration: 20 * time.Millisecond}}
+
+	b.Failure()
+	if !b.Allow() {
+		t.Fatal("breaker should stay closed below the threshold")
+	}
+	b.Failure()
+	if b.Allow() {
+		t.Fatal("breaker should open after the threshold")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("breaker should let a probe through after OpenDuration")
+	}
+	if b.Allow() {
+		t.Fatal("breaker should let only one probe through")
+	}
+
+	b.Failure()
+	if b.Allow() {
+		t.Fatal("failed probe should open the breaker again")
+	}
+	time.Sleep(30 * time.Millisecond)
+	b.Allow()
+	b.Success()
+	if !b.Allow() || !b.Allow() {
+		t.Fatal("successful probe should close the breaker")
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	var b *Breaker
+	b.Failure()
+	if !b.Allow() {
+		t.Error("nil breaker should allow requests")
+	}
+
+	b = &Breaker{}
+	for range 10 {
+		b.Failure()
+	}
+	if !b.Allow() {
+		t.Error("breaker without threshold should allow requests")
+	}
+}
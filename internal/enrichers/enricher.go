@@ -0,0 +1,117 @@
+package enrichers
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrNotConfigured = errors.New("enricher is not configured")
+
+// ErrNoSignal возвращают провайдеры, у которых нет данных для запроса (локальный провайдер
+// для неизвестного имени, пол по отчеству без признаков). Это не сбой: цепочка просто
+// переходит к следующему провайдеру
+var ErrNoSignal = errors.New("provider has no data for the query")
+
+// Query содержит данные человека, по которым провайдеры определяют возраст, пол и национальность.
+// CountryID - код страны ISO 3166-1 alpha-2, уточняющий запрос у провайдеров, которые его поддерживают
+type Query struct {
+	Name       string
+	Surname    string
+	Patronymic string
+	CountryID  string
+}
+
+// Значение атрибута равно nil, если провайдер ответил, но не смог его определить.
+// Provider - имя провайдера, который вернул результат
+type AgeResult struct {
+	Age      *int   `json:"age"`
+	Count    int    `json:"count"`
+	Provider string `json:"provider,omitempty"`
+}
+
+type GenderResult struct {
+	Gender      *string `json:"gender"`
+	Probability float64 `json:"probability"`
+	Count       int     `json:"count"`
+	Provider    string  `json:"provider,omitempty"`
+}
+
+type NationalityResult struct {
+	Nationality *string         `json:"nationality"`
+	Probability float64         `json:"probability"`
+	Countries   []CountryResult `json:"countries,omitempty"`
+	Provider    string          `json:"provider,omitempty"`
+}
+
+// CountryResult - один из кандидатов национальности, кандидаты упорядочены по убыванию вероятности
+type CountryResult struct {
+	CountryID   string  `json:"country_id"`
+	Probability float64 `json:"probability"`
+}
+
+// Result объединяет полученные атрибуты; nil означает, что атрибут не был получен
+type Result struct {
+	Age         *AgeResult         `json:"age,omitempty"`
+	Gender      *GenderResult      `json:"gender,omitempty"`
+	Nationality *NationalityResult `json:"nationality,omitempty"`
+}
+
+// Merge возвращает копию r, в которой недостающие атрибуты взяты из other
+func (r Result) Merge(other Result) Result {
+	if r.Age == nil {
+		r.Age = other.Age
+	}
+	if r.Gender == nil {
+		r.Gender = other.Gender
+	}
+	if r.Nationality == nil {
+		r.Nationality = other.Nationality
+	}
+	return r
+}
+
+type AgeEnricher interface {
+	EnrichAge(ctx context.Context, q Query) (*AgeResult, error)
+}
+
+type GenderEnricher interface {
+	EnrichGender(ctx context.Context, q Query) (*GenderResult, error)
+}
+
+type NationalityEnricher interface {
+	EnrichNationality(ctx context.Context, q Query) (*NationalityResult, error)
+}
+
+type Enricher interface {
+	AgeEnricher
+	GenderEnricher
+	NationalityEnricher
+}
+
+// Set собирает Enricher из отдельных провайдеров для каждого атрибута
+type Set struct {
+	Age         AgeEnricher
+	Gender      GenderEnricher
+	Nationality NationalityEnricher
+}
+
+func (s *Set) EnrichAge(ctx context.Context, q Query) (*AgeResult, error) {
+	if s.Age == nil {
+		return nil, ErrNotConfigured
+	}
+	return s.Age.EnrichAge(ctx, q)
+}
+
+func (s *Set) EnrichGender(ctx context.Context, q Query) (*GenderResult, error) {
+	if s.Gender == nil {
+		return nil, ErrNotConfigured
+	}
+	return s.Gender.EnrichGender(ctx, q)
+}
+
+func (s *Set) EnrichNationality(ctx context.Context, q Query) (*NationalityResult, error) {
+	if s.Nationality == nil {
+		return nil, ErrNotConfigured
+	}
+	return s.Nationality.EnrichNationality(ctx, q)
+}
@@ -0,0 +1,197 @@
+package enrichers
+
+import (
+	"context"
+	"errors"
+)
+
+// ChainSettings - цепочка провайдеров одного атрибута. MinConfidence - минимальная уверенность,
+// при которой ответ принимается: вероятность для пола и национальности, размер выборки для возраста
+type ChainSettings struct {
+	Providers     []string
+	MinConfidence float64
+}
+
+// AgeFallback и аналоги опрашивают провайдеров по порядку, пока один из них не определит
+// значение с уверенностью не ниже MinConfidence. Если такого ответа нет, возвращается
+// определённое значение с наибольшей уверенностью. Если значение не определил никто,
+// а хотя бы один провайдер ответил ошибкой, возвращаются все ошибки, чтобы сбой не выглядел
+// как неизвестное значение; иначе - ответ без значения. ErrNoSignal и ErrNotConfigured
+// ошибкой не считаются: провайдеру просто нечего сказать
+type AgeFallback struct {
+	Providers     []AgeEnricher
+	MinConfidence float64
+}
+
+type GenderFallback struct {
+	Providers     []GenderEnricher
+	MinConfidence float64
+}
+
+type NationalityFallback struct {
+	Providers     []NationalityEnricher
+	MinConfidence float64
+}
+
+func (f *AgeFallback) EnrichAge(ctx context.Context, q Query) (*AgeResult, error) {
+	return fallbackOne(ctx, q, f.Providers, AgeEnricher.EnrichAge, f.judge())
+}
+
+func (f *GenderFallback) EnrichGender(ctx context.Context, q Query) (*GenderResult, error) {
+	return fallbackOne(ctx, q, f.Providers, GenderEnricher.EnrichGender, f.judge())
+}
+
+func (f *NationalityFallback) EnrichNationality(ctx context.Context, q Query) (*NationalityResult, error) {
+	return fallbackOne(ctx, q, f.Providers, NationalityEnricher.EnrichNationality, f.judge())
+}
+
+func (f *AgeFallback) EnrichAgeBatch(ctx context.Context, qs []Query) ([]*AgeResult, []error) {
+	return fallbackBatch(ctx, qs, f.Providers, ageBatch, f.judge())
+}
+
+func (f *GenderFallback) EnrichGenderBatch(ctx context.Context, qs []Query) ([]*GenderResult, []error) {
+	return fallbackBatch(ctx, qs, f.Providers, genderBatch, f.judge())
+}
+
+func (f *NationalityFallback) EnrichNationalityBatch(ctx context.Context, qs []Query) ([]*NationalityResult, []error) {
+	return fallbackBatch(ctx, qs, f.Providers, nationalityBatch, f.judge())
+}
+
+func (f *AgeFallback) judge() judge[AgeResult] {
+	return judge[AgeResult]{
+		known:      func(r *AgeResult) bool { return r.Age != nil },
+		confidence: func(r *AgeResult) float64 { return float64(r.Count) },
+		min:        f.MinConfidence,
+	}
+}
+
+func (f *GenderFallback) judge() judge[GenderResult] {
+	return judge[GenderResult]{
+		known:      func(r *GenderResult) bool { return r.Gender != nil },
+		confidence: func(r *GenderResult) float64 { return r.Probability },
+		min:        f.MinConfidence,
+	}
+}
+
+func (f *NationalityFallback) judge() judge[NationalityResult] {
+	return judge[NationalityResult]{
+		known:      func(r *NationalityResult) bool { return r.Nationality != nil },
+		confidence: func(r *NationalityResult) float64 { return r.Probability },
+		min:        f.MinConfidence,
+	}
+}
+
+// judge решает, принять ли ответ провайдера или спросить следующего
+type judge[R any] struct {
+	known      func(*R) bool
+	confidence func(*R) float64
+	min        float64
+}
+
+func (j judge[R]) accepted(r *R) bool {
+	return j.known(r) && j.confidence(r) >= j.min
+}
+
+// better выбирает лучший из ответов, ни один из которых не был принят
+func (j judge[R]) better(best, r *R) *R {
+	switch {
+	case best == nil:
+		return r
+	case !j.known(r):
+		return best
+	case !j.known(best) || j.confidence(r) > j.confidence(best):
+		return r
+	default:
+		return best
+	}
+}
+
+func fallbackOne[E any, R any](ctx context.Context, q Query, chain []E, call func(E, context.Context, Query) (*R, error), j judge[R]) (*R, error) {
+	var (
+		best *R
+		errs []error
+	)
+	for _, e := range chain {
+		res, err := call(e, ctx, q)
+		if silent(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if j.accepted(res) {
+			return res, nil
+		}
+		best = j.better(best, res)
+	}
+	return j.outcome(best, errs)
+}
+
+// silent сообщает, что провайдер не дал ответа, но и не сломался
+func silent(err error) bool {
+	return errors.Is(err, ErrNoSignal) || errors.Is(err, ErrNotConfigured)
+}
+
+// outcome выбирает итог цепочки, в которой ни один ответ не был принят
+func (j judge[R]) outcome(best *R, errs []error) (*R, error) {
+	switch {
+	case best != nil && j.known(best):
+		return best, nil
+	case len(errs) > 0:
+		return nil, errors.Join(errs...)
+	case best != nil:
+		return best, nil
+	default:
+		return new(R), nil
+	}
+}
+
+// fallbackBatch передаёт следующему провайдеру только те запросы, для которых
+// предыдущие не дали принятого ответа
+func fallbackBatch[E any, R any](ctx context.Context, qs []Query, chain []E, batch func(context.Context, E, []Query) ([]*R, []error), j judge[R]) ([]*R, []error) {
+	results := make([]*R, len(qs))
+	errs := make([][]error, len(qs))
+	accepted := make([]bool, len(qs))
+
+	pending := make([]int, len(qs))
+	for i := range qs {
+		pending[i] = i
+	}
+	for _, e := range chain {
+		if len(pending) == 0 {
+			break
+		}
+		sub := make([]Query, len(pending))
+		for k, i := range pending {
+			sub[k] = qs[i]
+		}
+
+		res, batchErrs := batch(ctx, e, sub)
+		next := []int{}
+		for k, i := range pending {
+			switch {
+			case silent(batchErrs[k]):
+				next = append(next, i)
+			case batchErrs[k] != nil:
+				errs[i] = append(errs[i], batchErrs[k])
+				next = append(next, i)
+			case j.accepted(res[k]):
+				results[i] = res[k]
+				accepted[i] = true
+			default:
+				results[i] = j.better(results[i], res[k])
+				next = append(next, i)
+			}
+		}
+		pending = next
+	}
+
+	out := make([]error, len(qs))
+	for i := range qs {
+		if !accepted[i] {
+			results[i], out[i] = j.outcome(results[i], errs[i])
+		}
+	}
+	return results, out
+}
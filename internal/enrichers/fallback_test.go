@@ -0,0 +1,156 @@
+package enrichers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+var (
+	errProvider = errors.New("provider is down")
+	errOther    = errors.New("another provider is down")
+)
+
+// stubGender отвечает по имени из answers; имя без ответа получает ErrNoSignal
+type stubGender struct {
+	name    string
+	answers map[string]stubAnswer
+
+	mu    sync.Mutex
+	asked []string
+}
+
+type stubAnswer struct {
+	gender      string
+	probability float64
+	err         error
+}
+
+func (s *stubGender) EnrichGender(ctx context.Context, q Query) (*GenderResult, error) {
+	s.mu.Lock()
+	s.asked = append(s.asked, q.Name)
+	s.mu.Unlock()
+
+	a, ok := s.answers[q.Name]
+	switch {
+	case !ok:
+		return nil, ErrNoSignal
+	case a.err != nil:
+		return nil, a.err
+	}
+	res := &GenderResult{Probability: a.probability, Provider: s.name}
+	if a.gender != "" {
+		res.Gender = &a.gender
+	}
+	return res, nil
+}
+
+func stub(name string, a stubAnswer) *stubGender {
+	return &stubGender{name: name, answers: map[string]stubAnswer{"Ivan": a}}
+}
+
+func TestFallbackOne(t *testing.T) {
+	tests := []struct {
+		name     string
+		chain    []stubAnswer
+		winner   string
+		gender   string
+		errs     []error
+		asked    int
+		unknown  bool
+		noResult bool
+	}{
+		{"first accepted stops the chain", []stubAnswer{{"male", 0.9, nil}, {"female", 0.99, nil}}, "p0", "male", nil, 1, false, false},
+		{"exactly min confidence is accepted", []stubAnswer{{"male", 0.8, nil}, {"female", 0.99, nil}}, "p0", "male", nil, 1, false, false},
+		{"below min confidence asks the next", []stubAnswer{{"male", 0.6, nil}, {"female", 0.85, nil}}, "p1", "female", nil, 2, false, false},
+		{"most confident of the rejected", []stubAnswer{{"male", 0.7, nil}, {"female", 0.6, nil}}, "p0", "male", nil, 2, false, false},
+		{"known beats unknown", []stubAnswer{{"", 0, nil}, {"female", 0.5, nil}}, "p1", "female", nil, 2, false, false},
+		{"known beats errors", []stubAnswer{{err: errProvider}, {"female", 0.5, nil}}, "p1", "female", nil, 2, false, false},
+		{"no signal is skipped", []stubAnswer{{err: ErrNoSignal}, {"male", 0.9, nil}}, "p1", "male", nil, 2, false, false},
+		{"not configured is skipped", []stubAnswer{{err: ErrNotConfigured}, {"", 0, nil}}, "p1", "", nil, 2, true, false},
+		{"errors surface when nobody knows", []stubAnswer{{err: errProvider}, {"", 0, nil}, {err: errOther}}, "", "", []error{errProvider, errOther}, 3, false, true},
+		{"silent errors are not surfaced", []stubAnswer{{err: ErrNoSignal}, {err: ErrNotConfigured}}, "", "", nil, 2, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := []GenderEnricher{}
+			stubs := []*stubGender{}
+			for i, a := range tt.chain {
+				s := stub(fmt.Sprintf("p%d", i), a)
+				chain = append(chain, s)
+				stubs = append(stubs, s)
+			}
+			f := &GenderFallback{Providers: chain, MinConfidence: 0.8}
+
+			res, err := f.EnrichGender(context.Background(), Query{Name: "Ivan"})
+			checkOutcome(t, res, err, tt.winner, tt.gender, tt.errs, tt.unknown, tt.noResult)
+
+			asked := 0
+			for _, s := range stubs {
+				asked += len(s.asked)
+			}
+			if asked != tt.asked {
+				t.Errorf("providers asked %d times; want %d", asked, tt.asked)
+			}
+		})
+	}
+}
+
+func checkOutcome(t *testing.T, res *GenderResult, err error, winner, gender string, errs []error, unknown, noResult bool) {
+	t.Helper()
+	for _, want := range errs {
+		if !errors.Is(err, want) {
+			t.Errorf("error = %v; want it to include %v", err, want)
+		}
+	}
+	if len(errs) == 0 && err != nil {
+		t.Errorf("error = %v; want nil", err)
+	}
+	switch {
+	case noResult:
+		if res != nil {
+			t.Errorf("result = %+v; want nil", res)
+		}
+	case res == nil:
+		t.Errorf("result = nil; want one")
+	case unknown:
+		if res.Gender != nil {
+			t.Errorf("gender = %s; want unknown", *res.Gender)
+		}
+	case res.Gender == nil || *res.Gender != gender || res.Provider != winner:
+		t.Errorf("result = %v by %q; want %s by %s", res.Gender, res.Provider, gender, winner)
+	}
+}
+
+func TestFallbackBatch(t *testing.T) {
+	first := &stubGender{name: "first", answers: map[string]stubAnswer{
+		"Ivan":  {"male", 0.95, nil},
+		"Sasha": {"female", 0.55, nil},
+		"Kim":   {err: errProvider},
+		"Lee":   {err: errProvider},
+	}}
+	second := &stubGender{name: "second", answers: map[string]stubAnswer{
+		"Sasha": {"male", 0.5, nil},
+		"Kim":   {"female", 0.9, nil},
+		"Lee":   {"", 0, nil},
+		"Nemo":  {err: ErrNotConfigured},
+	}}
+	f := &GenderFallback{Providers: []GenderEnricher{first, second}, MinConfidence: 0.8}
+
+	qs := []Query{{Name: "Ivan"}, {Name: "Sasha"}, {Name: "Kim"}, {Name: "Lee"}, {Name: "Nemo"}}
+	results, errs := f.EnrichGenderBatch(context.Background(), qs)
+
+	checkOutcome(t, results[0], errs[0], "first", "male", nil, false, false)
+	checkOutcome(t, results[1], errs[1], "first", "female", nil, false, false)
+	checkOutcome(t, results[2], errs[2], "second", "female", nil, false, false)
+	checkOutcome(t, results[3], errs[3], "", "", []error{errProvider}, false, true)
+	checkOutcome(t, results[4], errs[4], "", "", nil, true, false)
+
+	asked := slices.Sorted(slices.Values(second.asked))
+	if want := []string{"Kim", "Lee", "Nemo", "Sasha"}; !slices.Equal(asked, want) {
+		t.Errorf("second provider asked for %v; want %v, accepted names must not be asked again", asked, want)
+	}
+}
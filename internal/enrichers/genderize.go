@@ -0,0 +1,47 @@
+package enrichers
+
+import (
+	"context"
+)
+
+const GenderizeURL = "https://api.genderize.io/"
+
+type Genderize struct {
+	HTTP
+}
+
+type genderizeResponse struct {
+	Name        string  `json:"name"`
+	Gender      *string `json:"gender"`
+	Probability float64 `json:"probability"`
+	Count       int     `json:"count"`
+}
+
+func (r genderizeResponse) result() *GenderResult {
+	return &GenderResult{Gender: r.Gender, Probability: r.Probability, Count: r.Count, Provider: ProviderGenderize}
+}
+
+func (g *Genderize) EnrichGender(ctx context.Context, q Query) (*GenderResult, error) {
+	var data genderizeResponse
+	if err := g.getJSON(ctx, g.params(q, true), &data); err != nil {
+		return nil, wrapError(ctx, "gender", err)
+	}
+	return data.result(), nil
+}
+
+func (g *Genderize) EnrichGenders(ctx context.Context, qs []Query) ([]*GenderResult, error) {
+	var data []genderizeResponse
+	if err := g.getJSON(ctx, g.multiParams(qs, true), &data); err != nil {
+		return nil, wrapError(ctx, "gender", err)
+	}
+	matched, err := matchByName(qs, data, func(r genderizeResponse) string { return r.Name })
+	if err != nil {
+		return nil, wrapError(ctx, "gender", err)
+	}
+
+	results := make([]*GenderResult, len(matched))
+	for i, r := range matched {
+		results[i] = r.result()
+	}
+	return results, nil
+}
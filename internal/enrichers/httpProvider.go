@@ -0,0 +1,239 @@
+package enrichers
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const maxErrorBodyLength = 256
+
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+type StatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
+func (e *StatusError) retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// noData сообщает, что провайдер ответил, но не смог обработать само имя; в отличие
+// от ошибок авторизации и настройки (401, 403) это не признак неисправности провайдера
+func (e *StatusError) noData() bool {
+	return e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusUnprocessableEntity
+}
+
+// ProviderSettings - настройки HTTP-провайдера из конфигурации
+type ProviderSettings struct {
+	Enabled      bool
+	URL          string
+	APIKey       string
+	Timeout      time.Duration
+	CountryID    string
+	QuotaReserve int
+}
+
+// HTTP - общая часть провайдеров, работающих через HTTP API. Name - имя провайдера
+// в реестре, под которым его запросы попадают в журнал обмена
+type HTTP struct {
+	Name      string
+	Client    *http.Client
+	URL       string
+	APIKey    string
+	Timeout   time.Duration
+	CountryID string
+	Retry     RetryPolicy
+	Breaker   *Breaker
+	Quotas    *QuotaTracker
+}
+
+func (h *HTTP) Quota() Quota {
+	return h.Quotas.Quota()
+}
+
+// params возвращает параметры запроса для имени; country_id передаётся только провайдерам,
+// которые его поддерживают (countryHint): страна из запроса, иначе страна из настроек провайдера
+func (h *HTTP) params(q Query, countryHint bool) url.Values {
+	params := url.Values{"name": {q.Name}}
+	if country := cmp.Or(q.CountryID, h.CountryID); countryHint && country != "" {
+		params.Set("country_id", country)
+	}
+	return params
+}
+
+// multiParams возвращает параметры пакетного запроса для нескольких имён. runBatch
+// группирует запросы так, что у всех qs одна и та же страна
+func (h *HTTP) multiParams(qs []Query, countryHint bool) url.Values {
+	params := url.Values{}
+	for _, q := range qs {
+		params.Add("name[]", q.Name)
+	}
+	if len(qs) > 0 {
+		if country := cmp.Or(qs[0].CountryID, h.CountryID); countryHint && country != "" {
+			params.Set("country_id", country)
+		}
+	}
+	return params
+}
+
+// getJSON выполняет GET-запрос с повторами и экспоненциальной задержкой,
+// учитывая Retry-After, и декодирует ответ в out
+func (h *HTTP) getJSON(ctx context.Context, params url.Values, out any) error {
+	if quota := h.Quota(); quota.Exhausted() {
+		return &QuotaError{ResetAt: quota.ResetAt}
+	}
+	if !h.Breaker.Allow() {
+		return ErrCircuitOpen
+	}
+	if h.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.Timeout)
+		defer cancel()
+	}
+
+	attempts := max(h.Retry.MaxAttempts, 1)
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		var body []byte
+		body, err = h.do(ctx, params)
+		if err == nil {
+			h.Breaker.Success()
+			if err := json.Unmarshal(body, out); err != nil {
+				return fmt.Errorf("malformed response: %w", err)
+			}
+			return nil
+		}
+
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && !statusErr.retryable() {
+			// Провайдер работает, но не знает имя; ошибки ключа и адреса сами не пройдут
+			if statusErr.noData() {
+				h.Breaker.Success()
+			} else {
+				h.Breaker.Failure()
+			}
+			return err
+		}
+		if ctx.Err() != nil || attempt == attempts {
+			break
+		}
+
+		delay := h.backoff(attempt)
+		if statusErr != nil && statusErr.RetryAfter > delay {
+			delay = statusErr.RetryAfter
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+	}
+
+	h.Breaker.Failure()
+	if quota := h.Quota(); quota.Exhausted() {
+		return fmt.Errorf("%w: %w", &QuotaError{ResetAt: quota.ResetAt}, err)
+	}
+	return err
+}
+
+func (h *HTTP) do(ctx context.Context, params url.Values) ([]byte, error) {
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	exchange := Exchange{Provider: h.Name, URL: recordedURL(h.URL, params), Names: slices.Concat(params["name"], params["name[]"]), At: time.Now()}
+	body, statusCode, err := h.send(ctx, client, params)
+	if recorder := recorderFrom(ctx); recorder != nil {
+		exchange.Latency = time.Since(exchange.At)
+		exchange.StatusCode = statusCode
+		exchange.Body = string(body[:min(len(body), maxRecordedBodyLength)])
+		if err != nil {
+			exchange.Error = err.Error()
+		}
+		recorder.record(exchange)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// send выполняет запрос; тело ответа возвращается и при ошибочном статусе, чтобы попасть в журнал
+func (h *HTTP) send(ctx context.Context, client *http.Client, params url.Values) ([]byte, int, error) {
+	if h.APIKey != "" {
+		params.Set("apikey", h.APIKey)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	h.Quotas.Update(resp)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return body, resp.StatusCode, &StatusError{StatusCode: resp.StatusCode, Body: string(body[:min(len(body), maxErrorBodyLength)]), RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	}
+	return body, resp.StatusCode, nil
+}
+
+func (h *HTTP) backoff(attempt int) time.Duration {
+	if h.Retry.BaseDelay <= 0 {
+		return 0
+	}
+	delay := h.Retry.BaseDelay << (attempt - 1)
+	if h.Retry.MaxDelay > 0 && (delay > h.Retry.MaxDelay || delay <= 0) {
+		delay = h.Retry.MaxDelay
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+func wrapError(ctx context.Context, attribute string, err error) error {
+	if ctx.Err() == context.DeadlineExceeded || errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("timeout exceeded while getting %s", attribute)
+	}
+	return fmt.Errorf("cannot get %s: %w", attribute, err)
+}
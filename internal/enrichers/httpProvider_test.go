@@ -0,0 +1,55 @@
+package enrichers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"empty", "", 0, 0},
+		{"seconds", "3", 3 * time.Second, 3 * time.Second},
+		{"http date", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 58 * time.Second, time.Minute},
+		{"garbage", "soon", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseRetryAfter(tt.value)
+			if got < tt.min || got > tt.max {
+				t.Errorf("parseRetryAfter(%q) = %v; want between %v and %v", tt.value, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	h := &HTTP{Retry: RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}}
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 300 * time.Millisecond},
+		{10, 300 * time.Millisecond},
+		{70, 300 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			delay := h.backoff(tt.attempt)
+			if delay < tt.max/2 || delay > tt.max {
+				t.Fatalf("backoff(%d) = %v; want between %v and %v", tt.attempt, delay, tt.max/2, tt.max)
+			}
+		}
+	}
+
+	if delay := (&HTTP{}).backoff(3); delay != 0 {
+		t.Errorf("backoff without BaseDelay = %v; want 0", delay)
+	}
+}
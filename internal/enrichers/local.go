@@ -0,0 +1,247 @@
+package enrichers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// LocalPrimary - локальные данные запрашиваются раньше HTTP-провайдеров
+	LocalPrimary = "primary"
+	// LocalFallback - локальные данные используются, когда HTTP-провайдеры не ответили
+	// или не определили значение
+	LocalFallback = "fallback"
+)
+
+// Local - провайдер, который берёт возраст, пол и национальность из локальных файлов
+// статистики имён в формате CSV или JSON. Нужен там, где HTTP-провайдеры недоступны.
+//
+// CSV-файл должен содержать заголовок; обязательна только колонка name:
+//
+//	name,age,age_count,gender,gender_probability,gender_count,countries
+//	ivan,42,1200,male,0.99,5400,RU:0.82;UA:0.09
+//
+// JSON-файл - массив объектов с теми же полями, countries - массив {country_id, probability}.
+// Если имя встречается несколько раз, используется последняя запись. Для имён и атрибутов,
+// которых нет в файлах, возвращается ErrNoSignal
+type Local struct {
+	Files []string
+
+	records atomic.Pointer[map[string]localRecord]
+}
+
+type localRecord struct {
+	Name              string          `json:"name"`
+	Age               *int            `json:"age"`
+	AgeCount          int             `json:"age_count"`
+	Gender            *string         `json:"gender"`
+	GenderProbability float64         `json:"gender_probability"`
+	GenderCount       int             `json:"gender_count"`
+	Countries         []CountryResult `json:"countries"`
+}
+
+// NewLocal создаёт провайдер и сразу загружает файлы
+func NewLocal(files []string) (*Local, error) {
+	l := &Local{Files: files}
+	if _, err := l.Reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Reload перечитывает файлы и возвращает количество загруженных имён.
+// При ошибке остаются данные предыдущей загрузки
+func (l *Local) Reload() (int, error) {
+	records := map[string]localRecord{}
+	for _, file := range l.Files {
+		if err := loadLocalFile(file, records); err != nil {
+			return 0, fmt.Errorf("cannot load %s: %w", file, err)
+		}
+	}
+	l.records.Store(&records)
+	return len(records), nil
+}
+
+func (l *Local) lookup(ctx context.Context, q Query) (localRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return localRecord{}, err
+	}
+	records := l.records.Load()
+	if records == nil {
+		return localRecord{}, ErrNotConfigured
+	}
+	record, ok := (*records)[localKey(q.Name)]
+	if !ok {
+		return localRecord{}, ErrNoSignal
+	}
+	return record, nil
+}
+
+func (l *Local) EnrichAge(ctx context.Context, q Query) (*AgeResult, error) {
+	r, err := l.lookup(ctx, q)
+	if err != nil {
+		return nil, wrapError(ctx, "age", err)
+	}
+	if r.Age == nil {
+		return nil, wrapError(ctx, "age", ErrNoSignal)
+	}
+	return &AgeResult{Age: r.Age, Count: r.AgeCount, Provider: ProviderLocal}, nil
+}
+
+func (l *Local) EnrichGender(ctx context.Context, q Query) (*GenderResult, error) {
+	r, err := l.lookup(ctx, q)
+	if err != nil {
+		return nil, wrapError(ctx, "gender", err)
+	}
+	if r.Gender == nil {
+		return nil, wrapError(ctx, "gender", ErrNoSignal)
+	}
+	return &GenderResult{Gender: r.Gender, Probability: r.GenderProbability, Count: r.GenderCount, Provider: ProviderLocal}, nil
+}
+
+func (l *Local) EnrichNationality(ctx context.Context, q Query) (*NationalityResult, error) {
+	r, err := l.lookup(ctx, q)
+	if err != nil {
+		return nil, wrapError(ctx, "nationality", err)
+	}
+	if len(r.Countries) == 0 {
+		return nil, wrapError(ctx, "nationality", ErrNoSignal)
+	}
+	return &NationalityResult{Nationality: &r.Countries[0].CountryID, Probability: r.Countries[0].Probability, Countries: r.Countries, Provider: ProviderLocal}, nil
+}
+
+func localKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func loadLocalFile(file string, records map[string]localRecord) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var loaded []localRecord
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		err = json.NewDecoder(f).Decode(&loaded)
+	case ".csv":
+		loaded, err = readLocalCSV(f)
+	default:
+		return fmt.Errorf("unsupported file format: %s", filepath.Ext(file))
+	}
+	if err != nil {
+		return err
+	}
+
+	for i, r := range loaded {
+		key := localKey(r.Name)
+		if key == "" {
+			return fmt.Errorf("record %d: name is required", i+1)
+		}
+		records[key] = r
+	}
+	return nil
+}
+
+func readLocalCSV(r io.Reader) ([]localRecord, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("name column is required")
+	}
+
+	records := []localRecord{}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		record, err := parseLocalRow(row, columns)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+}
+
+func parseLocalRow(row []string, columns map[string]int) (localRecord, error) {
+	value := func(column string) string {
+		if i, ok := columns[column]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	record := localRecord{Name: value("name")}
+	var err error
+	if age := value("age"); age != "" {
+		n, err := strconv.Atoi(age)
+		if err != nil {
+			return record, fmt.Errorf("invalid age: %w", err)
+		}
+		record.Age = &n
+	}
+	if gender := value("gender"); gender != "" {
+		record.Gender = &gender
+	}
+	if record.AgeCount, err = parseLocalInt(value("age_count")); err != nil {
+		return record, fmt.Errorf("invalid age_count: %w", err)
+	}
+	if record.GenderCount, err = parseLocalInt(value("gender_count")); err != nil {
+		return record, fmt.Errorf("invalid gender_count: %w", err)
+	}
+	if record.GenderProbability, err = parseLocalFloat(value("gender_probability")); err != nil {
+		return record, fmt.Errorf("invalid gender_probability: %w", err)
+	}
+
+	// countries: "RU:0.82;UA:0.09", вероятность можно не указывать
+	for _, country := range strings.Split(value("countries"), ";") {
+		id, probability, _ := strings.Cut(strings.TrimSpace(country), ":")
+		if id == "" {
+			continue
+		}
+		p, err := parseLocalFloat(probability)
+		if err != nil {
+			return record, fmt.Errorf("invalid probability of %s: %w", id, err)
+		}
+		record.Countries = append(record.Countries, CountryResult{CountryID: strings.ToUpper(id), Probability: p})
+	}
+	return record, nil
+}
+
+func parseLocalInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
+
+func parseLocalFloat(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
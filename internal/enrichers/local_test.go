@@ -0,0 +1,163 @@
+package enrichers
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func testdata(name string) string {
+	return filepath.Join("testdata", name)
+}
+
+func TestLocalLookup(t *testing.T) {
+	l, err := NewLocal([]string{testdata("names.csv"), testdata("names.json")})
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		q           Query
+		age         int
+		gender      string
+		nationality string
+		countries   int
+	}{
+		// Последняя запись побеждает: ivan из JSON заменяет Ivan из CSV целиком
+		{"later file wins", Query{Name: "Ivan"}, 40, "", "", 0},
+		{"csv with empty columns", Query{Name: "MARIA"}, 0, "female", "", 0},
+		{"country without probability", Query{Name: "kim"}, 0, "", "KR", 1},
+		{"json", Query{Name: " Olga "}, 35, "female", "RU", 2},
+		{"country in query is ignored", Query{Name: "Olga", CountryID: "KZ"}, 35, "female", "RU", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			age, err := l.EnrichAge(ctx, tt.q)
+			switch {
+			case tt.age == 0 && !errors.Is(err, ErrNoSignal):
+				t.Errorf("EnrichAge error = %v; want ErrNoSignal", err)
+			case tt.age != 0 && (err != nil || *age.Age != tt.age || age.Provider != ProviderLocal):
+				t.Errorf("EnrichAge = %+v, %v; want %d from local", age, err, tt.age)
+			}
+
+			gender, err := l.EnrichGender(ctx, tt.q)
+			switch {
+			case tt.gender == "" && !errors.Is(err, ErrNoSignal):
+				t.Errorf("EnrichGender error = %v; want ErrNoSignal", err)
+			case tt.gender != "" && (err != nil || *gender.Gender != tt.gender):
+				t.Errorf("EnrichGender = %+v, %v; want %s", gender, err, tt.gender)
+			}
+
+			nationality, err := l.EnrichNationality(ctx, tt.q)
+			switch {
+			case tt.nationality == "" && !errors.Is(err, ErrNoSignal):
+				t.Errorf("EnrichNationality error = %v; want ErrNoSignal", err)
+			case tt.nationality != "" && (err != nil || *nationality.Nationality != tt.nationality || len(nationality.Countries) != tt.countries):
+				t.Errorf("EnrichNationality = %+v, %v; want %s with %d countries", nationality, err, tt.nationality, tt.countries)
+			}
+		})
+	}
+
+	if _, err := l.EnrichAge(ctx, Query{Name: "Nobody"}); !errors.Is(err, ErrNoSignal) {
+		t.Errorf("EnrichAge for unknown name error = %v; want ErrNoSignal", err)
+	}
+}
+
+func TestLocalCSVCountries(t *testing.T) {
+	l, err := NewLocal([]string{testdata("names.csv")})
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	nationality, err := l.EnrichNationality(context.Background(), Query{Name: "Ivan"})
+	if err != nil {
+		t.Fatalf("EnrichNationality: %v", err)
+	}
+	want := []CountryResult{{CountryID: "RU", Probability: 0.82}, {CountryID: "UA", Probability: 0.09}}
+	if len(nationality.Countries) != len(want) {
+		t.Fatalf("countries = %+v; want %+v", nationality.Countries, want)
+	}
+	for i := range want {
+		if nationality.Countries[i] != want[i] {
+			t.Errorf("country %d = %+v; want %+v", i, nationality.Countries[i], want[i])
+		}
+	}
+}
+
+func TestLocalBadFiles(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"bad_age.csv", "line 3: invalid age"},
+		{"no_name_column.csv", "name column is required"},
+		{"empty_name.json", "record 2: name is required"},
+		{"names.txt", "unsupported file format"},
+		{"missing.csv", "no such file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			_, err := NewLocal([]string{testdata(tt.file)})
+			if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), tt.file) {
+				t.Errorf("NewLocal error = %v; want it to mention %s and %q", err, tt.file, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalReload(t *testing.T) {
+	l, err := NewLocal([]string{testdata("names.csv")})
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	ctx := context.Background()
+
+	// Неудачная перезагрузка оставляет прежние данные
+	l.Files = []string{testdata("names.json"), testdata("bad_age.csv")}
+	if _, err := l.Reload(); err == nil {
+		t.Fatalf("Reload with a bad file succeeded")
+	}
+	if age, err := l.EnrichAge(ctx, Query{Name: "Ivan"}); err != nil || *age.Age != 42 {
+		t.Errorf("EnrichAge after failed reload = %+v, %v; want previous data (42)", age, err)
+	}
+	if _, err := l.EnrichAge(ctx, Query{Name: "Olga"}); !errors.Is(err, ErrNoSignal) {
+		t.Errorf("EnrichAge for Olga after failed reload error = %v; want ErrNoSignal, partial data must not be visible", err)
+	}
+
+	l.Files = []string{testdata("names.json")}
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				age, err := l.EnrichAge(ctx, Query{Name: "Ivan"})
+				if err != nil || (*age.Age != 42 && *age.Age != 40) {
+					t.Errorf("EnrichAge during reload = %+v, %v; want 42 or 40", age, err)
+					return
+				}
+			}
+		}()
+	}
+	n, err := l.Reload()
+	wg.Wait()
+	if err != nil || n != 2 {
+		t.Fatalf("Reload = %d, %v; want 2 names", n, err)
+	}
+	if age, err := l.EnrichAge(ctx, Query{Name: "Ivan"}); err != nil || *age.Age != 40 {
+		t.Errorf("EnrichAge after reload = %+v, %v; want 40", age, err)
+	}
+	if _, err := l.EnrichAge(ctx, Query{Name: "Kim"}); !errors.Is(err, ErrNoSignal) {
+		t.Errorf("EnrichAge for a removed name error = %v; want ErrNoSignal", err)
+	}
+}
+
+func TestLocalNotLoaded(t *testing.T) {
+	if _, err := (&Local{}).EnrichAge(context.Background(), Query{Name: "Ivan"}); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("EnrichAge before loading error = %v; want ErrNotConfigured", err)
+	}
+}
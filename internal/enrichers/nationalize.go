@@ -0,0 +1,50 @@
+package enrichers
+
+import (
+	"context"
+)
+
+const NationalizeURL = "https://api.nationalize.io/"
+
+type Nationalize struct {
+	HTTP
+}
+
+type nationalizeResponse struct {
+	Name    string          `json:"name"`
+	Country []CountryResult `json:"country"`
+}
+
+func (r nationalizeResponse) result() *NationalityResult {
+	result := &NationalityResult{Countries: r.Country, Provider: ProviderNationalize}
+	if len(r.Country) > 0 {
+		result.Nationality = &r.Country[0].CountryID
+		result.Probability = r.Country[0].Probability
+	}
+	return result
+}
+
+func (n *Nationalize) EnrichNationality(ctx context.Context, q Query) (*NationalityResult, error) {
+	var data nationalizeResponse
+	if err := n.getJSON(ctx, n.params(q, false), &data); err != nil {
+		return nil, wrapError(ctx, "nationality", err)
+	}
+	return data.result(), nil
+}
+
+func (n *Nationalize) EnrichNationalities(ctx context.Context, qs []Query) ([]*NationalityResult, error) {
+	var data []nationalizeResponse
+	if err := n.getJSON(ctx, n.multiParams(qs, false), &data); err != nil {
+		return nil, wrapError(ctx, "nationality", err)
+	}
+	matched, err := matchByName(qs, data, func(r nationalizeResponse) string { return r.Name })
+	if err != nil {
+		return nil, wrapError(ctx, "nationality", err)
+	}
+
+	results := make([]*NationalityResult, len(matched))
+	for i, r := range matched {
+		results[i] = r.result()
+	}
+	return results, nil
+}
@@ -0,0 +1,122 @@
+package enrichers
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+const (
+	genderMale   = "male"
+	genderFemale = "female"
+
+	// Отчество однозначно определяет пол, окончание фамилии - почти всегда. Латинские -ov/-ova,
+	// -ev/-eva и -aya встречаются и в нерусских фамилиях (Casanova, Villanueva, Amaya), поэтому
+	// им верим меньше
+	patronymicProbability   = 1.0
+	surnameProbability      = 0.9
+	latinSurnameProbability = 0.6
+)
+
+// Окончания даны в кириллице и в латинице по ICAO (Достоевская -> Dostoevskaia) и ГОСТ 7.79-2000
+// (Достоевский -> Dostoevskij). Из подходящих окончаний выбирается самое длинное. Латинские -in/-ina
+// не используются: они слишком часто встречаются в нерусских фамилиях (Martin, Medina)
+var (
+	patronymicEndings = []genderEnding{
+		{"ovna", genderFemale, patronymicProbability}, {"evna", genderFemale, patronymicProbability},
+		{"ichna", genderFemale, patronymicProbability}, {"kyzy", genderFemale, patronymicProbability},
+		{"gyzy", genderFemale, patronymicProbability},
+		{"овна", genderFemale, patronymicProbability}, {"евна", genderFemale, patronymicProbability},
+		{"ична", genderFemale, patronymicProbability}, {"кызы", genderFemale, patronymicProbability},
+		{"гызы", genderFemale, patronymicProbability},
+		{"ovich", genderMale, patronymicProbability}, {"evich", genderMale, patronymicProbability},
+		{"ich", genderMale, patronymicProbability}, {"ogly", genderMale, patronymicProbability},
+		{"oglu", genderMale, patronymicProbability}, {"uly", genderMale, patronymicProbability},
+		{"ович", genderMale, patronymicProbability}, {"евич", genderMale, patronymicProbability},
+		{"ич", genderMale, patronymicProbability}, {"оглы", genderMale, patronymicProbability},
+		{"улы", genderMale, patronymicProbability},
+	}
+	surnameEndings = []genderEnding{
+		{"ская", genderFemale, surnameProbability}, {"цкая", genderFemale, surnameProbability},
+		{"ова", genderFemale, surnameProbability}, {"ева", genderFemale, surnameProbability},
+		{"ёва", genderFemale, surnameProbability}, {"ина", genderFemale, surnameProbability},
+		{"ына", genderFemale, surnameProbability}, {"ая", genderFemale, surnameProbability},
+		{"ский", genderMale, surnameProbability}, {"цкий", genderMale, surnameProbability},
+		{"ской", genderMale, surnameProbability}, {"ов", genderMale, surnameProbability},
+		{"ев", genderMale, surnameProbability}, {"ёв", genderMale, surnameProbability},
+		{"ин", genderMale, surnameProbability}, {"ын", genderMale, surnameProbability},
+
+		{"skaya", genderFemale, surnameProbability}, {"skaia", genderFemale, surnameProbability},
+		{"aia", genderFemale, surnameProbability},
+		{"ova", genderFemale, latinSurnameProbability}, {"eva", genderFemale, latinSurnameProbability},
+		{"aya", genderFemale, latinSurnameProbability},
+		{"skiy", genderMale, surnameProbability}, {"skii", genderMale, surnameProbability},
+		{"skij", genderMale, surnameProbability}, {"sky", genderMale, surnameProbability},
+		{"ski", genderMale, surnameProbability}, {"skoy", genderMale, surnameProbability},
+		{"skoi", genderMale, surnameProbability}, {"skoj", genderMale, surnameProbability},
+		{"kij", genderMale, surnameProbability}, {"koj", genderMale, surnameProbability},
+		{"ov", genderMale, latinSurnameProbability}, {"ev", genderMale, latinSurnameProbability},
+	}
+)
+
+type genderEnding struct {
+	suffix      string
+	gender      string
+	probability float64
+}
+
+// PatronymicGender определяет пол по окончанию русского отчества (-ович/-овна) в кириллице
+// и латинице. Такой ответ однозначен, поэтому провайдер стоит в цепочке первым. Если отчества
+// нет или фамилия указывает на другой пол, возвращается ErrNoSignal, и цепочка переходит
+// к следующему провайдеру
+type PatronymicGender struct{}
+
+// SurnameGender определяет пол по окончанию фамилии (-ов/-ова, -ский/-ская). Это лишь
+// догадка, поэтому в цепочке провайдер стоит после HTTP-провайдеров и отвечает, только
+// когда они не определили пол уверенно. Если признаков нет или отчество указывает на другой
+// пол, возвращается ErrNoSignal
+type SurnameGender struct{}
+
+func (PatronymicGender) EnrichGender(ctx context.Context, q Query) (*GenderResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, wrapError(ctx, "gender", err)
+	}
+	byPatronymic, bySurname := genderSignals(q)
+	if byPatronymic == nil || (bySurname != nil && bySurname.gender != byPatronymic.gender) {
+		return nil, ErrNoSignal
+	}
+	return &GenderResult{Gender: &byPatronymic.gender, Probability: byPatronymic.probability, Provider: ProviderPatronymic}, nil
+}
+
+func (SurnameGender) EnrichGender(ctx context.Context, q Query) (*GenderResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, wrapError(ctx, "gender", err)
+	}
+	byPatronymic, bySurname := genderSignals(q)
+	if bySurname == nil || (byPatronymic != nil && byPatronymic.gender != bySurname.gender) {
+		return nil, ErrNoSignal
+	}
+	return &GenderResult{Gender: &bySurname.gender, Probability: bySurname.probability, Provider: ProviderSurname}, nil
+}
+
+// genderSignals возвращает окончания отчества и фамилии, указывающие на пол, или nil
+func genderSignals(q Query) (byPatronymic, bySurname *genderEnding) {
+	// У двойной фамилии смотрим на последнюю непустую часть
+	surname := ""
+	if parts := strings.FieldsFunc(q.Surname, func(r rune) bool { return r == '-' || unicode.IsSpace(r) }); len(parts) > 0 {
+		surname = parts[len(parts)-1]
+	}
+	return matchEnding(q.Patronymic, patronymicEndings), matchEnding(surname, surnameEndings)
+}
+
+func matchEnding(word string, endings []genderEnding) *genderEnding {
+	word = strings.ToLower(strings.TrimSpace(word))
+	var match *genderEnding
+	for i, e := range endings {
+		// Окончание должно быть короче слова: "Ов" или "Ин" - не фамилии с окончанием
+		if len(word) > len(e.suffix) && strings.HasSuffix(word, e.suffix) && (match == nil || len(e.suffix) > len(match.suffix)) {
+			match = &endings[i]
+		}
+	}
+	return match
+}
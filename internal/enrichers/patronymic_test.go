@@ -0,0 +1,132 @@
+package enrichers
+
+import (
+	"EfectiveMobile/pkg/translit"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPatronymicGender(t *testing.T) {
+	tests := []struct {
+		name        string
+		q           Query
+		gender      string
+		probability float64
+	}{
+		{"cyrillic male patronymic", Query{Patronymic: "Сергеевич"}, genderMale, patronymicProbability},
+		{"cyrillic female patronymic", Query{Patronymic: "Ивановна"}, genderFemale, patronymicProbability},
+		{"latin male patronymic", Query{Patronymic: "Petrovich"}, genderMale, patronymicProbability},
+		{"latin female patronymic", Query{Patronymic: "Nikolaevna"}, genderFemale, patronymicProbability},
+		{"ichna", Query{Patronymic: "Ильинична"}, genderFemale, patronymicProbability},
+		{"turkic ogly", Query{Patronymic: "Алиев оглы"}, genderMale, patronymicProbability},
+		{"turkic kyzy", Query{Patronymic: "Mamed kyzy"}, genderFemale, patronymicProbability},
+		{"agreeing surname", Query{Surname: "Иванова", Patronymic: "Петровна"}, genderFemale, patronymicProbability},
+		{"case and spaces", Query{Patronymic: "  PETROVICH "}, genderMale, patronymicProbability},
+		{"no patronymic", Query{Surname: "Иванов"}, "", 0},
+		{"unknown patronymic", Query{Patronymic: "Smith"}, "", 0},
+		{"conflicting surname", Query{Surname: "Иванова", Patronymic: "Петрович"}, "", 0},
+		{"ending only", Query{Patronymic: "Ич"}, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkGender(t, PatronymicGender{}, tt.q, tt.gender, tt.probability)
+		})
+	}
+}
+
+func TestSurnameGender(t *testing.T) {
+	tests := []struct {
+		name        string
+		q           Query
+		gender      string
+		probability float64
+	}{
+		{"cyrillic -ов", Query{Surname: "Иванов"}, genderMale, surnameProbability},
+		{"cyrillic -ова", Query{Surname: "Иванова"}, genderFemale, surnameProbability},
+		{"cyrillic -ский", Query{Surname: "Достоевский"}, genderMale, surnameProbability},
+		{"cyrillic -ская", Query{Surname: "Достоевская"}, genderFemale, surnameProbability},
+		{"cyrillic -цкая", Query{Surname: "Троцкая"}, genderFemale, surnameProbability},
+		{"cyrillic -ая", Query{Surname: "Толстая"}, genderFemale, surnameProbability},
+		{"cyrillic -ин", Query{Surname: "Пушкин"}, genderMale, surnameProbability},
+		{"cyrillic -ина", Query{Surname: "Пушкина"}, genderFemale, surnameProbability},
+		{"latin -sky", Query{Surname: "Tchaikovsky"}, genderMale, surnameProbability},
+		{"latin -skaya", Query{Surname: "Sharapova-Kovalevskaya"}, genderFemale, surnameProbability},
+		{"latin -ova is uncertain", Query{Surname: "Sharapova"}, genderFemale, latinSurnameProbability},
+		{"latin -ov is uncertain", Query{Surname: "Ivanov"}, genderMale, latinSurnameProbability},
+		{"casanova is only a guess", Query{Name: "Giovanni", Surname: "Casanova"}, genderFemale, latinSurnameProbability},
+		{"latin -aya is uncertain", Query{Surname: "Amaya"}, genderFemale, latinSurnameProbability},
+		{"latin -in is ignored", Query{Surname: "Martin"}, "", 0},
+		{"latin -ina is ignored", Query{Surname: "Medina"}, "", 0},
+		{"double surname uses last part", Query{Surname: "Иванова-Smith"}, "", 0},
+		{"double surname with space", Query{Surname: "Smith Иванова"}, genderFemale, surnameProbability},
+		{"trailing space", Query{Surname: "Иванова "}, genderFemale, surnameProbability},
+		{"trailing hyphen", Query{Surname: "Smith-Иванов-"}, genderMale, surnameProbability},
+		{"spaces around hyphen", Query{Surname: " Smith - Иванова\t"}, genderFemale, surnameProbability},
+		{"agreeing patronymic", Query{Surname: "Петров", Patronymic: "Иванович"}, genderMale, surnameProbability},
+		{"conflicting patronymic", Query{Surname: "Петров", Patronymic: "Ивановна"}, "", 0},
+		{"ending only", Query{Surname: "Ов"}, "", 0},
+		{"no surname", Query{Name: "Ivan"}, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkGender(t, SurnameGender{}, tt.q, tt.gender, tt.probability)
+		})
+	}
+}
+
+// Имена приходят к провайдеру транслитерированными стандартом из настроек
+func TestGenderTransliterated(t *testing.T) {
+	tests := []struct {
+		surname    string
+		patronymic string
+		gender     string
+	}{
+		{"Достоевский", "", genderMale},
+		{"Достоевская", "", genderFemale},
+		{"Троцкий", "", genderMale},
+		{"Троцкая", "", genderFemale},
+		{"Толстая", "", genderFemale},
+		{"Трубецкой", "", genderMale},
+		{"Иванова", "", genderFemale},
+		{"Ковалёв", "", genderMale},
+		{"", "Ильич", genderMale},
+		{"", "Сергеевна", genderFemale},
+		{"", "Кузьминична", genderFemale},
+	}
+	for _, std := range []translit.Standard{translit.ICAO, translit.GOST779} {
+		for _, tt := range tests {
+			q := Query{Surname: translit.Transliterate(tt.surname, std), Patronymic: translit.Transliterate(tt.patronymic, std)}
+			t.Run(string(std)+" "+q.Surname+q.Patronymic, func(t *testing.T) {
+				var e GenderEnricher = SurnameGender{}
+				if tt.patronymic != "" {
+					e = PatronymicGender{}
+				}
+				res, err := e.EnrichGender(context.Background(), q)
+				if err != nil {
+					t.Fatalf("EnrichGender(%+v) error = %v", q, err)
+				}
+				if *res.Gender != tt.gender {
+					t.Errorf("EnrichGender(%+v) = %s; want %s", q, *res.Gender, tt.gender)
+				}
+			})
+		}
+	}
+}
+
+func checkGender(t *testing.T, e GenderEnricher, q Query, gender string, probability float64) {
+	t.Helper()
+	res, err := e.EnrichGender(context.Background(), q)
+	if gender == "" {
+		if !errors.Is(err, ErrNoSignal) {
+			t.Errorf("EnrichGender(%+v) = %+v, %v; want ErrNoSignal", q, res, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("EnrichGender(%+v) error = %v", q, err)
+	}
+	if *res.Gender != gender || res.Probability != probability {
+		t.Errorf("EnrichGender(%+v) = %s %.2f; want %s %.2f", q, *res.Gender, res.Probability, gender, probability)
+	}
+}
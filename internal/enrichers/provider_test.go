@@ -0,0 +1,165 @@
+package enrichers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"EfectiveMobile/pkg/fakeenrich"
+)
+
+var fastRetry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func newFakeHTTP(name, url string, retry RetryPolicy, breaker *Breaker) HTTP {
+	return HTTP{Name: name, URL: url, Timeout: 5 * time.Second, Retry: retry, Breaker: breaker, Quotas: &QuotaTracker{}}
+}
+
+func TestProviderRetriesRateLimitAfterRetryAfter(t *testing.T) {
+	srv := fakeenrich.NewServer(fakeenrich.Options{RateLimitFirst: 1, RetryAfter: time.Second})
+	defer srv.Close()
+	agify := &Agify{HTTP: newFakeHTTP(ProviderAgify, srv.AgifyURL(), fastRetry, nil)}
+
+	start := time.Now()
+	result, err := agify.EnrichAge(context.Background(), Query{Name: "Dmitriy"})
+	if err != nil {
+		t.Fatalf("EnrichAge: %v", err)
+	}
+	if result.Age == nil {
+		t.Errorf("EnrichAge age = nil; want a value after retry")
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retry after %v; want at least Retry-After (1s)", elapsed)
+	}
+	if got := srv.Handler.Requests(fakeenrich.AgifyPath); got != 2 {
+		t.Errorf("requests = %d; want 2", got)
+	}
+}
+
+func TestProviderRetriesServerErrors(t *testing.T) {
+	srv := fakeenrich.NewServer(fakeenrich.Options{ErrorFirst: 2})
+	defer srv.Close()
+	genderize := &Genderize{HTTP: newFakeHTTP(ProviderGenderize, srv.GenderizeURL(), fastRetry, nil)}
+
+	result, err := genderize.EnrichGender(context.Background(), Query{Name: "Anna"})
+	if err != nil {
+		t.Fatalf("EnrichGender: %v", err)
+	}
+	if result.Gender == nil || *result.Gender != "female" {
+		t.Errorf("EnrichGender gender = %v; want female", result.Gender)
+	}
+	if got := srv.Handler.Requests(fakeenrich.GenderizePath); got != 3 {
+		t.Errorf("requests = %d; want 3", got)
+	}
+}
+
+func TestProviderGivesUpAfterMaxAttempts(t *testing.T) {
+	srv := fakeenrich.NewServer(fakeenrich.Options{ErrorFirst: 10})
+	defer srv.Close()
+	agify := &Agify{HTTP: newFakeHTTP(ProviderAgify, srv.AgifyURL(), fastRetry, nil)}
+
+	_, err := agify.EnrichAge(context.Background(), Query{Name: "Dmitriy"})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("EnrichAge error = %v; want status 500", err)
+	}
+	if got := srv.Handler.Requests(fakeenrich.AgifyPath); got != fastRetry.MaxAttempts {
+		t.Errorf("requests = %d; want %d", got, fastRetry.MaxAttempts)
+	}
+}
+
+func TestProviderNullResults(t *testing.T) {
+	srv := fakeenrich.NewServer(fakeenrich.Options{NullNames: []string{"Nobody"}})
+	defer srv.Close()
+	ctx := context.Background()
+	q := Query{Name: "Nobody"}
+
+	age, err := (&Agify{HTTP: newFakeHTTP(ProviderAgify, srv.AgifyURL(), fastRetry, nil)}).EnrichAge(ctx, q)
+	if err != nil || age.Age != nil {
+		t.Errorf("EnrichAge = %+v, %v; want nil age", age, err)
+	}
+	gender, err := (&Genderize{HTTP: newFakeHTTP(ProviderGenderize, srv.GenderizeURL(), fastRetry, nil)}).EnrichGender(ctx, q)
+	if err != nil || gender.Gender != nil {
+		t.Errorf("EnrichGender = %+v, %v; want nil gender", gender, err)
+	}
+	nationality, err := (&Nationalize{HTTP: newFakeHTTP(ProviderNationalize, srv.NationalizeURL(), fastRetry, nil)}).EnrichNationality(ctx, q)
+	if err != nil || nationality.Nationality != nil || len(nationality.Countries) != 0 {
+		t.Errorf("EnrichNationality = %+v, %v; want nil nationality", nationality, err)
+	}
+
+	ages, err := (&Agify{HTTP: newFakeHTTP(ProviderAgify, srv.AgifyURL(), fastRetry, nil)}).EnrichAges(ctx, []Query{{Name: "Nobody"}, {Name: "Dmitriy"}})
+	if err != nil {
+		t.Fatalf("EnrichAges: %v", err)
+	}
+	if ages[0].Age != nil || ages[1].Age == nil {
+		t.Errorf("EnrichAges = [%v %v]; want [nil, value]", ages[0].Age, ages[1].Age)
+	}
+}
+
+func TestProviderMalformedJSON(t *testing.T) {
+	srv := fakeenrich.NewServer(fakeenrich.Options{MalformedNames: []string{"Broken"}})
+	defer srv.Close()
+	agify := &Agify{HTTP: newFakeHTTP(ProviderAgify, srv.AgifyURL(), fastRetry, nil)}
+
+	if _, err := agify.EnrichAge(context.Background(), Query{Name: "Broken"}); err == nil {
+		t.Errorf("EnrichAge error = nil; want malformed response error")
+	}
+	if _, err := agify.EnrichAges(context.Background(), []Query{{Name: "Dmitriy"}, {Name: "Broken"}}); err == nil {
+		t.Errorf("EnrichAges error = nil; want malformed response error")
+	}
+	if got := srv.Handler.Requests(fakeenrich.AgifyPath); got != 2 {
+		t.Errorf("requests = %d; want 2, malformed responses are not retried", got)
+	}
+}
+
+func TestProviderBreakerOpens(t *testing.T) {
+	srv := fakeenrich.NewServer(fakeenrich.Options{ErrorRate: 1})
+	defer srv.Close()
+	breaker := &Breaker{BreakerSettings: BreakerSettings{Threshold: 2, OpenDuration: time.Minute}}
+	agify := &Agify{HTTP: newFakeHTTP(ProviderAgify, srv.AgifyURL(), RetryPolicy{MaxAttempts: 1}, breaker)}
+
+	for range breaker.Threshold {
+		if _, err := agify.EnrichAge(context.Background(), Query{Name: "Dmitriy"}); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("EnrichAge error = %v before threshold", err)
+		}
+	}
+	if _, err := agify.EnrichAge(context.Background(), Query{Name: "Dmitriy"}); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("EnrichAge error = %v; want ErrCircuitOpen", err)
+	}
+	if got := srv.Handler.Requests(fakeenrich.AgifyPath); got != breaker.Threshold {
+		t.Errorf("requests = %d; want %d, open breaker must not reach the provider", got, breaker.Threshold)
+	}
+}
+
+func TestBreakerCountsClientErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		opens  bool
+	}{
+		{"unauthorized", http.StatusUnauthorized, true},
+		{"forbidden", http.StatusForbidden, true},
+		{"not found", http.StatusNotFound, false},
+		{"unprocessable", http.StatusUnprocessableEntity, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+			breaker := &Breaker{BreakerSettings: BreakerSettings{Threshold: 2, OpenDuration: time.Minute}}
+			agify := &Agify{HTTP: newFakeHTTP(ProviderAgify, srv.URL, fastRetry, breaker)}
+
+			for range breaker.Threshold {
+				agify.EnrichAge(context.Background(), Query{Name: "Dmitriy"})
+			}
+			_, err := agify.EnrichAge(context.Background(), Query{Name: "Dmitriy"})
+			if opened := errors.Is(err, ErrCircuitOpen); opened != tt.opens {
+				t.Errorf("breaker open after %d responses = %v; want %v (error %v)", tt.status, opened, tt.opens, err)
+			}
+		})
+	}
+}
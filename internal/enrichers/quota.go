@@ -0,0 +1,102 @@
+package enrichers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var ErrQuotaExhausted = errors.New("provider quota is exhausted")
+
+// QuotaError - ошибка исчерпания квоты с временем её сброса; errors.Is(err, ErrQuotaExhausted)
+// для неё выполняется. Нулевой ResetAt означает, что время сброса неизвестно
+type QuotaError struct {
+	ResetAt time.Time
+}
+
+func (e *QuotaError) Error() string {
+	return ErrQuotaExhausted.Error()
+}
+
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExhausted
+}
+
+// QuotaResetAt возвращает время сброса квоты из ошибки; ok ложно, если это не ошибка квоты
+// или время сброса неизвестно
+func QuotaResetAt(err error) (resetAt time.Time, ok bool) {
+	var quotaErr *QuotaError
+	if !errors.As(err, &quotaErr) || quotaErr.ResetAt.IsZero() {
+		return time.Time{}, false
+	}
+	return quotaErr.ResetAt, true
+}
+
+type Quota struct {
+	Known     bool      `json:"known"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reserve   int       `json:"reserve"`
+	ResetAt   time.Time `json:"reset_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Exhausted сообщает, что остаток квоты опустился до резерва и ещё не сброшен
+func (q Quota) Exhausted() bool {
+	return q.Known && q.Remaining <= q.Reserve && time.Now().Before(q.ResetAt)
+}
+
+type QuotaReporter interface {
+	Quota() Quota
+}
+
+// QuotaTracker запоминает остаток дневной квоты провайдера по заголовкам X-Rate-Limit-*
+type QuotaTracker struct {
+	Reserve int
+
+	mu    sync.Mutex
+	quota Quota
+}
+
+func (t *QuotaTracker) Update(resp *http.Response) {
+	if t == nil {
+		return
+	}
+	remaining, err := strconv.Atoi(resp.Header.Get("X-Rate-Limit-Remaining"))
+	if err != nil {
+		if resp.StatusCode != http.StatusTooManyRequests {
+			return
+		}
+		remaining = 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.quota.Known = true
+	t.quota.Remaining = remaining
+	t.quota.UpdatedAt = now
+	if limit, err := strconv.Atoi(resp.Header.Get("X-Rate-Limit-Limit")); err == nil {
+		t.quota.Limit = limit
+	}
+	if reset, err := strconv.Atoi(resp.Header.Get("X-Rate-Limit-Reset")); err == nil {
+		t.quota.ResetAt = now.Add(time.Duration(reset) * time.Second)
+	} else if retryAfter := parseRetryAfter(resp.Header.Get("Retry-After")); retryAfter > 0 {
+		t.quota.ResetAt = now.Add(retryAfter)
+	}
+}
+
+func (t *QuotaTracker) Quota() Quota {
+	if t == nil {
+		return Quota{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	q := t.quota
+	q.Reserve = t.Reserve
+	return q
+}
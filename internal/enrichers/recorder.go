@@ -0,0 +1,79 @@
+package enrichers
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxRecordedBodyLength - сколько байт ответа сохраняется в журнале обмена
+const maxRecordedBodyLength = 64 << 10
+
+// Exchange - запрос к провайдеру и его ответ. URL не содержит API-ключа,
+// Names - имена, которые были в запросе
+type Exchange struct {
+	Provider   string
+	URL        string
+	Names      []string
+	StatusCode int
+	Body       string
+	Latency    time.Duration
+	Error      string
+	At         time.Time
+}
+
+// Recorder собирает обмены с провайдерами, выполненные с контекстом из WithRecorder
+type Recorder struct {
+	mu        sync.Mutex
+	exchanges []Exchange
+}
+
+type recorderKey struct{}
+
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+func recorderFrom(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return r
+}
+
+func (r *Recorder) record(e Exchange) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exchanges = append(r.exchanges, e)
+}
+
+// For возвращает обмены, в запросах которых было имя name
+func (r *Recorder) For(name string) []Exchange {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := []Exchange{}
+	for _, e := range r.exchanges {
+		if slices.Contains(e.Names, name) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// recordedURL возвращает адрес запроса без API-ключа
+func recordedURL(base string, params url.Values) string {
+	clean := url.Values{}
+	for key, values := range params {
+		if key != "apikey" {
+			clean[key] = values
+		}
+	}
+	return base + "?" + clean.Encode()
+}
@@ -0,0 +1,239 @@
+package enrichers
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+const (
+	ProviderAgify       = "agify"
+	ProviderGenderize   = "genderize"
+	ProviderNationalize = "nationalize"
+	ProviderLocal       = "local"
+	ProviderPatronymic  = "patronymic"
+	ProviderSurname     = "surname"
+)
+
+// Registry хранит провайдеров по имени, чтобы новые источники данных
+// подключались без изменений в PersonService
+type Registry struct {
+	mu            sync.RWMutex
+	ages          map[string]AgeEnricher
+	genders       map[string]GenderEnricher
+	nationalities map[string]NationalityEnricher
+}
+
+// NewDefaultRegistry регистрирует включённые провайдеры agify, genderize и nationalize
+// и встроенный провайдер пола по отчеству и фамилии. Настройки берутся из providers
+// по имени провайдера, у каждого HTTP-провайдера свой предохранитель
+func NewDefaultRegistry(client *http.Client, retry RetryPolicy, breaker BreakerSettings, providers map[string]ProviderSettings) *Registry {
+	newHTTP := func(name, defaultURL string) (HTTP, bool) {
+		settings := providers[name]
+		if settings.URL == "" {
+			settings.URL = defaultURL
+		}
+		return HTTP{
+			Name:      name,
+			Client:    client,
+			URL:       settings.URL,
+			APIKey:    settings.APIKey,
+			Timeout:   settings.Timeout,
+			CountryID: settings.CountryID,
+			Retry:     retry,
+			Breaker:   &Breaker{BreakerSettings: breaker},
+			Quotas:    &QuotaTracker{Reserve: settings.QuotaReserve},
+		}, settings.Enabled
+	}
+
+	r := &Registry{}
+	r.RegisterGender(ProviderPatronymic, PatronymicGender{})
+	r.RegisterGender(ProviderSurname, SurnameGender{})
+	if h, ok := newHTTP(ProviderAgify, AgifyURL); ok {
+		r.RegisterAge(ProviderAgify, &Agify{HTTP: h})
+	}
+	if h, ok := newHTTP(ProviderGenderize, GenderizeURL); ok {
+		r.RegisterGender(ProviderGenderize, &Genderize{HTTP: h})
+	}
+	if h, ok := newHTTP(ProviderNationalize, NationalizeURL); ok {
+		r.RegisterNationality(ProviderNationalize, &Nationalize{HTTP: h})
+	}
+	return r
+}
+
+// Register регистрирует провайдер, который определяет все три атрибута
+func (r *Registry) Register(name string, e Enricher) {
+	r.RegisterAge(name, e)
+	r.RegisterGender(name, e)
+	r.RegisterNationality(name, e)
+}
+
+func (r *Registry) RegisterAge(name string, e AgeEnricher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ages == nil {
+		r.ages = map[string]AgeEnricher{}
+	}
+	r.ages[name] = e
+}
+
+func (r *Registry) RegisterGender(name string, e GenderEnricher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.genders == nil {
+		r.genders = map[string]GenderEnricher{}
+	}
+	r.genders[name] = e
+}
+
+func (r *Registry) RegisterNationality(name string, e NationalityEnricher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.nationalities == nil {
+		r.nationalities = map[string]NationalityEnricher{}
+	}
+	r.nationalities[name] = e
+}
+
+func (r *Registry) Age(name string) (AgeEnricher, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.ages[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown age provider: %s", name)
+	}
+	return e, nil
+}
+
+func (r *Registry) Gender(name string) (GenderEnricher, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.genders[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown gender provider: %s", name)
+	}
+	return e, nil
+}
+
+func (r *Registry) Nationality(name string) (NationalityEnricher, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.nationalities[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown nationality provider: %s", name)
+	}
+	return e, nil
+}
+
+// Build собирает Set из провайдеров, зарегистрированных под указанными именами.
+// Пустое имя означает, что атрибут не обогащается
+func (r *Registry) Build(age, gender, nationality string) (*Set, error) {
+	set := &Set{}
+	var err error
+	if age != "" {
+		if set.Age, err = r.Age(age); err != nil {
+			return nil, err
+		}
+	}
+	if gender != "" {
+		if set.Gender, err = r.Gender(gender); err != nil {
+			return nil, err
+		}
+	}
+	if nationality != "" {
+		if set.Nationality, err = r.Nationality(nationality); err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+// BuildChains собирает Set, в котором каждый атрибут запрашивается у цепочки провайдеров
+// по порядку (см. AgeFallback). Пустая цепочка означает, что атрибут не обогащается
+func (r *Registry) BuildChains(age, gender, nationality ChainSettings) (*Set, error) {
+	set := &Set{}
+	ages, err := resolveChain(age.Providers, r.Age)
+	if err != nil {
+		return nil, err
+	}
+	genders, err := resolveChain(gender.Providers, r.Gender)
+	if err != nil {
+		return nil, err
+	}
+	nationalities, err := resolveChain(nationality.Providers, r.Nationality)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ages) > 0 {
+		set.Age = &AgeFallback{Providers: ages, MinConfidence: age.MinConfidence}
+	}
+	if len(genders) > 0 {
+		set.Gender = &GenderFallback{Providers: genders, MinConfidence: gender.MinConfidence}
+	}
+	if len(nationalities) > 0 {
+		set.Nationality = &NationalityFallback{Providers: nationalities, MinConfidence: nationality.MinConfidence}
+	}
+	return set, nil
+}
+
+func resolveChain[E any](names []string, get func(string) (E, error)) ([]E, error) {
+	chain := make([]E, 0, len(names))
+	for _, name := range names {
+		e, err := get(name)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, e)
+	}
+	return chain, nil
+}
+
+// Has сообщает, зарегистрирован ли провайдер с таким именем хотя бы для одного атрибута
+func (r *Registry) Has(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, age := r.ages[name]
+	_, gender := r.genders[name]
+	_, nationality := r.nationalities[name]
+	return age || gender || nationality
+}
+
+// Provides сообщает, зарегистрирован ли провайдер с таким именем для атрибута age, gender или nationality
+func (r *Registry) Provides(attribute, name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var ok bool
+	switch attribute {
+	case "age":
+		_, ok = r.ages[name]
+	case "gender":
+		_, ok = r.genders[name]
+	case "nationality":
+		_, ok = r.nationalities[name]
+	}
+	return ok
+}
+
+// Quotas возвращает остаток квоты всех провайдеров, которые его отслеживают
+func (r *Registry) Quotas() map[string]Quota {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	quotas := map[string]Quota{}
+	add := func(name string, e any) {
+		if reporter, ok := e.(QuotaReporter); ok {
+			quotas[name] = reporter.Quota()
+		}
+	}
+	for name, e := range r.ages {
+		add(name, e)
+	}
+	for name, e := range r.genders {
+		add(name, e)
+	}
+	for name, e := range r.nationalities {
+		add(name, e)
+	}
+	return quotas
+}
@@ -0,0 +1,106 @@
+// Package filter описывает условия выборки людей в виде дерева. Сервис строит дерево
+// из параметров запроса, а репозиторий компилирует его в SQL с плейсхолдерами, так что
+// значения из запроса никогда не попадают в текст SQL
+package filter
+
+import "fmt"
+
+// Field - поле человека, по которому можно фильтровать. Репозиторий знает колонку
+// только для перечисленных ниже полей
+type Field string
+
+const (
+	// ID - идентификатор человека; по нему можно только сортировать
+	ID                     Field = "id"
+	Name                   Field = "name"
+	Surname                Field = "surname"
+	Patronymic             Field = "patronymic"
+	NameTranslit           Field = "name_translit"
+	SurnameTranslit        Field = "surname_translit"
+	PatronymicTranslit     Field = "patronymic_translit"
+	Age                    Field = "age"
+	AgeCount               Field = "age_count"
+	Gender                 Field = "gender"
+	GenderProbability      Field = "gender_probability"
+	GenderCount            Field = "gender_count"
+	Nationality            Field = "nationality"
+	NationalityProbability Field = "nationality_probability"
+)
+
+type Operator string
+
+const (
+	Eq      Operator = "eq"
+	Ne      Operator = "ne"
+	Lt      Operator = "lt"
+	Gt      Operator = "gt"
+	Lte     Operator = "lte"
+	Gte     Operator = "gte"
+	IsNull  Operator = "isnull"
+	NotNull Operator = "notnull"
+	// In и NotIn - значение входит (не входит) в список; Value - непустой []any
+	In    Operator = "in"
+	NotIn Operator = "nin"
+	// Between - значение между границами включительно; Value - []any из двух значений
+	Between Operator = "between"
+	// Prefix, Contains и EqualFold сравнивают строки: начало, подстрока и равенство без учёта
+	// регистра. Value - строка, символы шаблонов LIKE в ней не действуют
+	Prefix    Operator = "prefix"
+	Contains  Operator = "contains"
+	EqualFold Operator = "ilike"
+	// Any - основная национальность или любая из списка вероятных национальностей
+	Any Operator = "any"
+)
+
+// Expr - узел дерева условий: Condition, And, Or или Not
+type Expr interface {
+	expr()
+}
+
+// Condition - условие на одно поле. Для IsNull и NotNull Value не задаётся
+type Condition struct {
+	Field    Field
+	Operator Operator
+	Value    any
+}
+
+// And выполняется, когда выполняются все условия; пустой And выполняется всегда
+type And []Expr
+
+// Or выполняется, когда выполняется хотя бы одно условие; пустой Or не выполняется никогда
+type Or []Expr
+
+// Not выполняется, когда не выполняется Expr
+type Not struct {
+	Expr Expr
+}
+
+func (Condition) expr() {}
+func (And) expr()       {}
+func (Or) expr()        {}
+func (Not) expr()       {}
+
+// Sort - поле сортировки; Desc - по убыванию
+type Sort struct {
+	Field Field
+	Desc  bool
+}
+
+// Query - условия выборки вместе с сортировкой и пагинацией; нулевые Limit и Offset
+// не ограничивают выборку
+type Query struct {
+	Where  Expr
+	Sort   []Sort
+	Limit  int
+	Offset int
+}
+
+// Error - ошибка в параметре фильтрации; Param - имя параметра запроса
+type Error struct {
+	Param   string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid %s param: %s", e.Param, e.Message)
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"EfectiveMobile/internal/dto"
+	"EfectiveMobile/internal/enrichers"
+	"EfectiveMobile/internal/filter"
+	"EfectiveMobile/internal/services"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	getCacheStats        = "/api/v1/admin/cache/stats"
+	invalidateCache      = "/api/v1/admin/cache"
+	invalidateCacheEntry = "/api/v1/admin/cache/{name}"
+	getQuotas            = "/api/v1/admin/quota"
+	reenrich             = "/api/v1/admin/reenrich"
+	reloadLocal          = "/api/v1/admin/local/reload"
+)
+
+type AdminHandler struct {
+	PersonService *services.PersonService
+	Cache         *services.EnrichmentCache
+	Registry      *enrichers.Registry
+	Local         *enrichers.Local
+	Log           *slog.Logger
+}
+
+func (ah *AdminHandler) Register(router *chi.Mux) {
+	router.Get(getCacheStats, ah.GetCacheStats)
+	ah.Log.Info("Successfully created http route", slog.String("route", getCacheStats))
+	router.Delete(invalidateCache, ah.InvalidateCache)
+	ah.Log.Info("Successfully created http route", slog.String("route", invalidateCache))
+	router.Delete(invalidateCacheEntry, ah.InvalidateCacheEntry)
+	ah.Log.Info("Successfully created http route", slog.String("route", invalidateCacheEntry))
+	router.Get(getQuotas, ah.GetQuotas)
+	ah.Log.Info("Successfully created http route", slog.String("route", getQuotas))
+	router.Post(reenrich, ah.Reenrich)
+	ah.Log.Info("Successfully created http route", slog.String("route", reenrich))
+	router.Post(reloadLocal, ah.ReloadLocal)
+	ah.Log.Info("Successfully created http route", slog.String("route", reloadLocal))
+}
+
+// @Summary Статистика кэша обогащения
+// @Description Возвращает количество попаданий и промахов кэша обогащения
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.EnrichmentCacheStats
+// @Router /api/v1/admin/cache/stats [get]
+func (ah *AdminHandler) GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ah.Cache.Stats())
+}
+
+// @Summary Очистка кэша обогащения
+// @Description Удаляет все записи кэша обогащения
+// @Tags admin
+// @Success 204 {string} string "Cache successfully invalidated"
+// @Failure 500 {string} string "Failed to invalidate cache"
+// @Router /api/v1/admin/cache [delete]
+func (ah *AdminHandler) InvalidateCache(w http.ResponseWriter, r *http.Request) {
+	if err := ah.Cache.InvalidateAll(); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to invalidate cache: %s", err.Error()), http.StatusInternalServerError)
+		ah.Log.Error("Failed to invalidate cache", slog.String("error", err.Error()))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Удаление записи кэша обогащения
+// @Description Удаляет из кэша обогащения записи для указанного имени во всех странах.
+// @Description Имя можно передать кириллицей или латиницей
+// @Tags admin
+// @Param name path string true "Имя"
+// @Success 204 {string} string "Cache entry successfully invalidated"
+// @Failure 500 {string} string "Failed to invalidate cache entry"
+// @Router /api/v1/admin/cache/{name} [delete]
+func (ah *AdminHandler) InvalidateCacheEntry(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if err := ah.PersonService.InvalidateName(name); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to invalidate cache entry: %s", err.Error()), http.StatusInternalServerError)
+		ah.Log.Error("Failed to invalidate cache entry", slog.String("name", name), slog.String("error", err.Error()))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Квоты провайдеров обогащения
+// @Description Возвращает остаток дневной квоты каждого провайдера по последнему ответу
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string]enrichers.Quota
+// @Router /api/v1/admin/quota [get]
+func (ah *AdminHandler) GetQuotas(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ah.Registry.Quotas())
+}
+
+// @Summary Повторное обогащение
+// @Description Повторно запрашивает возраст, пол и национальность у провайдеров (минуя кэш)
+// @Description для людей, выбранных по тем же фильтрам, что и /api/v1/person/get,
+// @Description и возвращает изменившиеся поля. При dry_run=true изменения не сохраняются
+// @Tags admin
+// @Produce json
+// @Param dry_run query bool false "Только показать изменения, не сохраняя их"
+// @Param name query string false "Имя пользователя"
+// @Param surname query string false "Фамилия пользователя"
+// @Param patronymic query string false "Отчество пользователя"
+// @Param gender query string false "Пол пользователя"
+// @Param nationality query string false "Национальность пользователя"
+// @Param age query string false "Возраст пользователя"
+// @Param limit query int false "Лимит записей"
+// @Param offset query int false "Смещение записей"
+// @Success 200 {object} dto.ReenrichReport
+// @Failure 400 {string} string "Invalid request parameters"
+// @Failure 500 {string} string "Failed to reenrich persons"
+// @Router /api/v1/admin/reenrich [post]
+func (ah *AdminHandler) Reenrich(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+	filters, err := ParseFilters(queryParams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		ah.Log.Error("Cannot parse filters", slog.String("error", err.Error()))
+		return
+	}
+
+	dryRun := false
+	if dryRunStr := queryParams.Get("dry_run"); dryRunStr != "" {
+		dryRun, err = strconv.ParseBool(dryRunStr)
+		if err != nil {
+			http.Error(w, "Invalid dry_run value", http.StatusBadRequest)
+			ah.Log.Error("Cannot get dry_run", slog.String("error", err.Error()))
+			return
+		}
+	}
+
+	report, err := ah.PersonService.Reenrich(filters, dryRun)
+	var filterErr *filter.Error
+	if errors.As(err, &filterErr) {
+		http.Error(w, filterErr.Error(), http.StatusBadRequest)
+		ah.Log.Error("Invalid filter", slog.String("error", err.Error()))
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to reenrich persons: %s", err.Error()), http.StatusInternalServerError)
+		ah.Log.Error("Failed to reenrich persons", slog.String("error", err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// @Summary Перезагрузка локальных данных обогащения
+// @Description Перечитывает CSV- и JSON-файлы локального провайдера (то же делает SIGHUP).
+// @Description При ошибке остаются ранее загруженные данные. Уже закэшированные результаты
+// @Description не меняются до очистки кэша
+// @Tags admin
+// @Produce json
+// @Success 200 {object} dto.LocalReloadResponse
+// @Failure 404 {string} string "Local provider is disabled"
+// @Failure 500 {string} string "Failed to reload local data"
+// @Router /api/v1/admin/local/reload [post]
+func (ah *AdminHandler) ReloadLocal(w http.ResponseWriter, r *http.Request) {
+	if ah.Local == nil {
+		http.Error(w, "Local provider is disabled", http.StatusNotFound)
+		return
+	}
+
+	names, err := ah.Local.Reload()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to reload local data: %s", err.Error()), http.StatusInternalServerError)
+		ah.Log.Error("Failed to reload local enrichment data", slog.String("error", err.Error()))
+		return
+	}
+	ah.Log.Info("Local enrichment data reloaded", slog.Int("names", names))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.LocalReloadResponse{Names: names})
+}
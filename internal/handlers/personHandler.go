@@ -2,11 +2,15 @@ package handlers
 
 import (
 	"EfectiveMobile/internal/dto"
+	"EfectiveMobile/internal/filter"
+	"EfectiveMobile/internal/models"
 	"EfectiveMobile/internal/services"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	_ "EfectiveMobile/docs" // Подключаем документацию
@@ -19,9 +23,15 @@ import (
 const (
 	getPersonByID     = "/api/v1/person/get/{id}"
 	getPersonByParams = "/api/v1/person/get"
+	searchPersons     = "/api/v1/person/search"
 	deletePersonByID  = "/api/v1/person/delete/{id}"
 	updatePerson      = "/api/v1/person/update"
 	createPerson      = "/api/v1/person/create"
+	createPersons     = "/api/v1/person/create/batch"
+	getEnrichmentLog  = "/api/v1/person/get/{id}/enrichment"
+
+	// maxCreateBatch - наибольшее число пользователей в одном запросе пакетного создания
+	maxCreateBatch = 100
 )
 
 type PersonHandler struct {
@@ -32,14 +42,20 @@ type PersonHandler struct {
 func (ph *PersonHandler) Register(router *chi.Mux) {
 	router.Get(getPersonByID, ph.GetPersonsByID)
 	ph.Log.Info("Successfully created http route", slog.String("route", getPersonByID))
+	router.Get(getEnrichmentLog, ph.GetEnrichmentLog)
+	ph.Log.Info("Successfully created http route", slog.String("route", getEnrichmentLog))
 	router.Get(getPersonByParams, ph.GetPersonsByParams)
 	ph.Log.Info("Successfully created http route", slog.String("route", getPersonByParams))
+	router.Post(searchPersons, ph.SearchPersons)
+	ph.Log.Info("Successfully created http route", slog.String("route", searchPersons))
 	router.Delete(deletePersonByID, ph.DeletePersonById)
 	ph.Log.Info("Successfully created http route", slog.String("route", deletePersonByID))
 	router.Put(updatePerson, ph.UpdatePerson)
 	ph.Log.Info("Successfully created http route", slog.String("route", updatePerson))
 	router.Post(createPerson, ph.CreatePerson)
 	ph.Log.Info("Successfully created http route", slog.String("route", createPerson))
+	router.Post(createPersons, ph.CreatePersons)
+	ph.Log.Info("Successfully created http route", slog.String("route", createPersons))
 	router.Get("/swagger/*", httpSwagger.WrapHandler)
 	ph.Log.Info("Swagger documentation is enabled")
 }
@@ -72,13 +88,65 @@ func (ph *PersonHandler) GetPersonsByID(w http.ResponseWriter, r *http.Request)
 	ph.Log.Debug("Encoded person to json", slog.Int("id", id))
 }
 
+// @Summary История обогащения человека
+// @Description Возвращает сохранённые запросы к провайдерам обогащения и их ответы
+// @Description (адрес без API-ключа, статус, тело, время ответа), новые записи первыми
+// @Tags person
+// @Produce json
+// @Param id path int true "ID человека"
+// @Param limit query int false "Лимит записей"
+// @Param offset query int false "Смещение записей"
+// @Success 200 {array} models.EnrichmentLogEntry
+// @Failure 400 {string} string "Invalid request parameters"
+// @Failure 500 {string} string "Failed to get enrichment log"
+// @Router /api/v1/person/get/{id}/enrichment [get]
+func (ph *PersonHandler) GetEnrichmentLog(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		ph.Log.Error("Cannot get id", slog.String("error", err.Error()))
+		return
+	}
+	filters, err := ParseFilters(url.Values{"limit": r.URL.Query()["limit"], "offset": r.URL.Query()["offset"]})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		ph.Log.Error("Cannot parse pagination", slog.String("error", err.Error()))
+		return
+	}
+
+	entries, err := ph.PersonService.GetEnrichmentLog(id, filters.ByLimit, filters.ByOffset)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get enrichment log: %s", err.Error()), http.StatusInternalServerError)
+		ph.Log.Error("Cannot get enrichment log", slog.Int("id", id), slog.String("error", err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
 // @Summary Получение отфильтрованной информации о людях
 // @Description Возвращает отфильтрованные данные о людях
 // @Description Операторы для фильтрации значений (не распространяется на limit и offset):
 // @Description - `var=is:X` — значение равно X
 // @Description - `var=isnt:X` — значение не равно X
-// @Description - `var=ls:X` — значение меньше X (только для age)
-// @Description - `var=mt:X` — значение больше X (только для age)
+// @Description - `var=ls:X` — значение меньше X (только для числовых полей)
+// @Description - `var=mt:X` — значение больше X (только для числовых полей)
+// @Description - `var=lte:X`, `var=gte:X` — значение не больше (не меньше) X (только для числовых полей)
+// @Description - `var=between:X,Y` — значение от X до Y включительно (только для числовых полей)
+// @Description - `var=in:X,Y,Z`, `var=nin:X,Y,Z` — значение входит (не входит) в список (для строковых полей и age)
+// @Description - `var=prefix:X` — значение начинается с X (только для строковых полей)
+// @Description - `var=contains:X` — значение содержит X (только для строковых полей)
+// @Description - `var=ilike:X` — значение равно X без учёта регистра (только для строковых полей)
+// @Description - `var=isnull` — значение неизвестно (для patronymic, age, gender, nationality)
+// @Description - `var=notnull` — значение известно (для patronymic, age, gender, nationality)
+// @Description - `nationality=any:X` — X совпадает с основной национальностью или любым из кандидатов
+// @Description - `name_translit=is:X` — поиск по транслитерации без учёта регистра; X можно передать и кириллицей
+// @Description - Параметр можно повторить, условия объединяются через AND: `age=mt:20&age=ls:40`
+// @Description - Альтернативы через `|` объединяются через OR: `nationality=is:RU|is:UA`
+// @Description - `or=var=op:X|var2=op:Y` — группа условий на разные поля, объединяемых через OR: `or=gender=is:female|age=ls:30`
+// @Description - Оператор отделяется от значения первым двоеточием, символы `%` и `_` в значении не являются шаблонами
+// @Description - `|` и `,` внутри значения экранируются обратной косой чертой, `\\` - сама черта: `name=is:A\|B`, `surname=in:A\,B,C`
 // @Description - Пример:
 // @Description - `age=mt:X` — значение больше X
 // @Description - `name=is:X` — значение равно X
@@ -87,9 +155,18 @@ func (ph *PersonHandler) GetPersonsByID(w http.ResponseWriter, r *http.Request)
 // @Param name query string false "Имя пользователя"
 // @Param surname query string false "Фамилия пользователя"
 // @Param patronymic query string false "Отчество пользователя"
+// @Param name_translit query string false "Транслитерация имени"
+// @Param surname_translit query string false "Транслитерация фамилии"
+// @Param patronymic_translit query string false "Транслитерация отчества"
 // @Param gender query string false "Пол пользователя"
 // @Param nationality query string false "Национальность пользователя"
-// @Param age query int false "Возраст пользователя"
+// @Param age query string false "Возраст пользователя"
+// @Param age_count query string false "Размер выборки, по которой определён возраст"
+// @Param gender_probability query string false "Вероятность пола (например, `mt:0.9`)"
+// @Param gender_count query string false "Размер выборки, по которой определён пол"
+// @Param nationality_probability query string false "Вероятность национальности"
+// @Param or query string false "Группа условий, объединяемых через OR"
+// @Param sort query string false "Сортировка: поля через запятую с направлением asc или desc, например `age:desc,surname:asc`; при равенстве - по id"
 // @Param limit query int false "Лимит записей (если не задан - выводятся все подходящие данные)"
 // @Param offset query int false "Смещение записей"
 // @Success 200 {array} models.Person
@@ -97,22 +174,107 @@ func (ph *PersonHandler) GetPersonsByID(w http.ResponseWriter, r *http.Request)
 // @Failure 500 {string} string "Failed to get persons"
 // @Router /api/v1/person/get [get]
 func (ph *PersonHandler) GetPersonsByParams(w http.ResponseWriter, r *http.Request) {
-	queryParams := r.URL.Query()
+	filters, err := ParseFilters(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		ph.Log.Error("Cannot parse filters", slog.String("error", err.Error()))
+		return
+	}
+
+	persons, err := ph.PersonService.GetPersonsByParams(filters)
+	var filterErr *filter.Error
+	if errors.As(err, &filterErr) {
+		http.Error(w, filterErr.Error(), http.StatusBadRequest)
+		ph.Log.Error("Invalid filter", slog.String("error", err.Error()))
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get person: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(persons)
+}
+
+// @Summary Поиск людей
+// @Description Ищет людей по JSON-документу с деревом условий. Узел дерева содержит ровно одно из:
+// @Description `and` или `or` (массив узлов), `not` (узел) или условие `field`, `operator`, `value`.
+// @Description Поля и операторы те же, что у /api/v1/person/get; для in, nin и between value - массив.
+// @Description Сортировка: поля id, name, surname, patronymic, age, age_count, gender, gender_probability,
+// @Description gender_count, nationality, nationality_probability; order - asc или desc; при равенстве - по id
+// @Description - Пример: `{"filter": {"or": [{"field": "nationality", "operator": "is", "value": "RU"}, {"field": "age", "operator": "between", "value": [20, 30]}]}, "sort": [{"field": "age", "order": "desc"}], "limit": 10}`
+// @Tags person
+// @Accept json
+// @Produce json
+// @Param search body dto.SearchPersons true "Условия поиска"
+// @Success 200 {array} models.Person
+// @Failure 400 {string} string "Invalid search request"
+// @Failure 500 {string} string "Failed to search persons"
+// @Router /api/v1/person/search [post]
+func (ph *PersonHandler) SearchPersons(w http.ResponseWriter, r *http.Request) {
+	var search dto.SearchPersons
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&search)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %s", err.Error()), http.StatusBadRequest)
+		ph.Log.Error("Cannot decoded search to json", slog.String("error", err.Error()))
+		return
+	}
+
+	validate := validator.New()
+	err = validate.Struct(search)
+	if err != nil {
+		http.Error(w, "Validation error: limit and offset must not be negative, sort needs a field and asc or desc order", http.StatusBadRequest)
+		ph.Log.Error("Validation error", slog.String("error", err.Error()))
+		return
+	}
+
+	persons, err := ph.PersonService.SearchPersons(&search)
+	var filterErr *filter.Error
+	if errors.As(err, &filterErr) {
+		http.Error(w, filterErr.Error(), http.StatusBadRequest)
+		ph.Log.Error("Invalid filter", slog.String("error", err.Error()))
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to search persons: %s", err.Error()), http.StatusInternalServerError)
+		ph.Log.Error("Failed to search persons", slog.String("error", err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(persons)
+}
+
+// ParseFilters разбирает параметры фильтрации списка людей; используется также
+// для выбора людей при повторном обогащении
+func ParseFilters(queryParams url.Values) (dto.Filters, error) {
 	filters := dto.Filters{}
-	filters.ByName = queryParams.Get("name")
-	filters.BySurname = queryParams.Get("surname")
-	filters.ByPatronymic = queryParams.Get("patronymic")
-	filters.ByGender = queryParams.Get("gender")
-	filters.ByNationality = queryParams.Get("nationality")
-	filters.ByAge = queryParams.Get("age")
+	filters.ByName = queryParams["name"]
+	filters.BySurname = queryParams["surname"]
+	filters.ByPatronymic = queryParams["patronymic"]
+	filters.ByNameTranslit = queryParams["name_translit"]
+	filters.BySurnameTranslit = queryParams["surname_translit"]
+	filters.ByPatronymicTranslit = queryParams["patronymic_translit"]
+	filters.ByGender = queryParams["gender"]
+	filters.ByNationality = queryParams["nationality"]
+	filters.ByAge = queryParams["age"]
+	filters.ByAgeCount = queryParams["age_count"]
+	filters.ByGenderProbability = queryParams["gender_probability"]
+	filters.ByGenderCount = queryParams["gender_count"]
+	filters.ByNationalityProbability = queryParams["nationality_probability"]
+	filters.Or = queryParams["or"]
+	filters.Sort = queryParams["sort"]
 
 	limitStr := queryParams.Get("limit")
 	if limitStr != "" {
 		limit, err := strconv.Atoi(limitStr)
-		if err != nil {
-			http.Error(w, "Invalid limit value", http.StatusBadRequest)
-			ph.Log.Error("Cannot get limit", slog.String("error", err.Error()))
-			return
+		if err != nil || limit < 0 {
+			return filters, fmt.Errorf("Invalid limit value")
 		}
 		filters.ByLimit = limit
 	}
@@ -120,23 +282,12 @@ func (ph *PersonHandler) GetPersonsByParams(w http.ResponseWriter, r *http.Reque
 	offsetStr := queryParams.Get("offset")
 	if offsetStr != "" {
 		offset, err := strconv.Atoi(offsetStr)
-		if err != nil {
-			http.Error(w, "Invalid offset value", http.StatusBadRequest)
-			ph.Log.Error("Cannot get offset", slog.String("error", err.Error()))
-			return
+		if err != nil || offset < 0 {
+			return filters, fmt.Errorf("Invalid offset value")
 		}
 		filters.ByOffset = offset
 	}
-
-	persons, err := ph.PersonService.GetPersonsByParams(filters)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get person: %s", err.Error()), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(persons)
+	return filters, nil
 }
 
 // @Summary Создание нового пользователя
@@ -145,9 +296,14 @@ func (ph *PersonHandler) GetPersonsByParams(w http.ResponseWriter, r *http.Reque
 // @Accept json
 // @Produce json
 // @Param person body dto.CreatePerson true "Данные пользователя для создания"
-// @Success 201 {object} int "ID нового пользователя"
+// @Description Если включено асинхронное обогащение, пользователь сохраняется сразу со статусом
+// @Description обогащения pending, а данные запрашиваются в фоне. Если фоновую задачу не удалось
+// @Description поставить в очередь, пользователь всё равно создан и возвращается его ID со статусом failed
+// @Success 201 {object} dto.CreatePersonResponse "ID нового пользователя и результат обогащения"
+// @Success 202 {object} dto.CreatePersonResponse "ID нового пользователя, обогащение выполняется в фоне"
 // @Failure 400 {string} string "Invalid JSON"
 // @Failure 500 {string} string "Failed to create person"
+// @Failure 503 {string} string "Provider quota is exhausted"
 // @Router /api/v1/person/create [post]
 func (ph *PersonHandler) CreatePerson(w http.ResponseWriter, r *http.Request) {
 	var person dto.CreatePerson
@@ -162,21 +318,79 @@ func (ph *PersonHandler) CreatePerson(w http.ResponseWriter, r *http.Request) {
 	validate := validator.New()
 	err = validate.Struct(person)
 	if err != nil {
-		http.Error(w, "Validation error: name and surname are required", http.StatusBadRequest)
+		http.Error(w, "Validation error: name and surname are required, country_hint must be a two-letter country code", http.StatusBadRequest)
 		ph.Log.Error("Validation error", slog.String("error", err.Error()))
 		return
 	}
 
-	id, err := ph.PersonService.CreatePerson(&person)
+	resp, err := ph.PersonService.CreatePerson(&person)
+	if errors.Is(err, services.ErrUnavailable) {
+		http.Error(w, fmt.Sprintf("Failed to create person: %s", err.Error()), http.StatusServiceUnavailable)
+		ph.Log.Warn("Enrichment is unavailable", slog.String("error", err.Error()))
+		return
+	}
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to create person: %s", err.Error()), http.StatusInternalServerError)
 		ph.Log.Error("Failed to create person", slog.String("error", err.Error()))
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	if resp.Enrichment != nil && resp.Enrichment.Status == models.EnrichmentPending {
+		w.WriteHeader(http.StatusAccepted)
+	}
+
+	err = json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode JSON: %s", err.Error()), http.StatusInternalServerError)
+		ph.Log.Error("Failed to encode JSON", slog.String("error", err.Error()))
+		return
+	}
+}
+
+// @Summary Пакетное создание пользователей
+// @Description Создает нескольких пользователей; одинаковые имена обогащаются одним запросом,
+// @Description остальные группируются в пакетные запросы к провайдерам.
+// @Description Результат для каждого пользователя возвращается на той же позиции, что и в запросе.
+// @Description В одном запросе можно создать не больше 100 пользователей
+// @Tags person
+// @Accept json
+// @Produce json
+// @Param persons body []dto.CreatePerson true "Данные пользователей для создания (не больше 100)"
+// @Success 200 {array} dto.CreatePersonResponse "ID новых пользователей и результаты обогащения"
+// @Failure 400 {string} string "Invalid JSON"
+// @Router /api/v1/person/create/batch [post]
+func (ph *PersonHandler) CreatePersons(w http.ResponseWriter, r *http.Request) {
+	var persons []dto.CreatePerson
+
+	err := json.NewDecoder(r.Body).Decode(&persons)
+	if err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		ph.Log.Error("Cannot decoded persons to json", slog.String("error", err.Error()))
+		return
+	}
+
+	validate := validator.New()
+	err = validate.Var(persons, fmt.Sprintf("max=%d", maxCreateBatch))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Validation error: at most %d persons can be created at once", maxCreateBatch), http.StatusBadRequest)
+		ph.Log.Error("Validation error", slog.Int("persons", len(persons)), slog.String("error", err.Error()))
+		return
+	}
+	for i, person := range persons {
+		err = validate.Struct(person)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Validation error: name and surname are required, country_hint must be a two-letter country code (person %d)", i), http.StatusBadRequest)
+			ph.Log.Error("Validation error", slog.Int("person", i), slog.String("error", err.Error()))
+			return
+		}
+	}
+
+	resp := ph.PersonService.CreatePersons(persons)
+
 	w.Header().Set("Content-Type", "application/json")
 
-	err = json.NewEncoder(w).Encode(id)
+	err = json.NewEncoder(w).Encode(resp)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to encode JSON: %s", err.Error()), http.StatusInternalServerError)
 		ph.Log.Error("Failed to encode JSON", slog.String("error", err.Error()))
@@ -214,6 +428,8 @@ func (ph *PersonHandler) DeletePersonById(w http.ResponseWriter, r *http.Request
 
 // @Summary Обновление данных пользователя
 // @Description Обновляет данные пользователя с переданными новыми данными
+// @Description Переданные age, gender и nationality отмечаются в provenance как manual, и обогащение их больше не перезаписывает.
+// @Description Поля из reset снова отдаются обогащению и запрашиваются у провайдеров в фоне
 // @Tags person
 // @Accept json
 // @Produce json
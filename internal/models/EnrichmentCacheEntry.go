@@ -0,0 +1,12 @@
+package models
+
+import (
+	"EfectiveMobile/internal/enrichers"
+	"time"
+)
+
+type EnrichmentCacheEntry struct {
+	Name      string
+	Result    enrichers.Result
+	ExpiresAt time.Time
+}
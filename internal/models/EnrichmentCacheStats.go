@@ -0,0 +1,9 @@
+package models
+
+type EnrichmentCacheStats struct {
+	Hits       int64 `json:"hits"`
+	Misses     int64 `json:"misses"`
+	MemoryHits int64 `json:"memory_hits"`
+	DBHits     int64 `json:"db_hits"`
+	Size       int   `json:"size"`
+}
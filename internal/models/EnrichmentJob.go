@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+type EnrichmentJob struct {
+	ID         int
+	PersonID   int
+	Attributes []string
+	Attempts   int
+	RunAt      time.Time
+	LastError  string
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// EnrichmentLogEntry - запрос к провайдеру обогащения и его ответ, сохранённые для аудита
+type EnrichmentLogEntry struct {
+	ID         int64     `json:"id"`
+	PersonID   int       `json:"person_id"`
+	Provider   string    `json:"provider"`
+	URL        string    `json:"url"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Body       string    `json:"body,omitempty"`
+	LatencyMs  int64     `json:"latency_ms"`
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+const (
+	// SourceEnriched - значение получено от провайдера обогащения
+	SourceEnriched = "enriched"
+	// SourceManual - значение исправлено пользователем, обогащение его не перезаписывает
+	SourceManual = "manual"
+)
+
+// FieldProvenance описывает происхождение значения обогащаемого поля
+type FieldProvenance struct {
+	Source    string    `json:"source"`
+	Provider  string    `json:"provider,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -1,11 +1,29 @@
 package models
 
+const (
+	EnrichmentPending = "pending"
+	EnrichmentDone    = "done"
+	EnrichmentFailed  = "failed"
+)
+
 type Person struct {
-	ID          int    `json:"id,omitempty"`
-	Name        string `json:"name"`
-	Surname     string `json:"surname"`
-	Patronymic  string `json:"patronymic,omitempty"`
-	Age         int    `json:"age"`
-	Gender      string `json:"gender"`
-	Nationality string `json:"nationality"`
+	ID                     int                 `json:"id,omitempty"`
+	Name                   string              `json:"name"`
+	Surname                string              `json:"surname"`
+	Patronymic             string              `json:"patronymic,omitempty"`
+	NameTranslit           string              `json:"name_translit"`
+	SurnameTranslit        string              `json:"surname_translit"`
+	PatronymicTranslit     string              `json:"patronymic_translit,omitempty"`
+	CountryHint            string              `json:"country_hint,omitempty"`
+	Age                    *int                `json:"age"`
+	AgeCount               int                 `json:"age_count"`
+	Gender                 *string             `json:"gender"`
+	GenderProbability      float64             `json:"gender_probability"`
+	GenderCount            int                 `json:"gender_count"`
+	Nationality            *string             `json:"nationality"`
+	NationalityProbability float64             `json:"nationality_probability"`
+	Nationalities          []PersonNationality `json:"nationalities"`
+	EnrichmentStatus       string              `json:"enrichment_status"`
+	// Provenance - происхождение значений age, gender и nationality по имени поля
+	Provenance map[string]FieldProvenance `json:"provenance"`
 }
@@ -0,0 +1,7 @@
+package models
+
+type PersonNationality struct {
+	CountryID   string  `json:"country_id"`
+	Probability float64 `json:"probability"`
+	Rank        int     `json:"rank"`
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"EfectiveMobile/internal/models"
+	"context"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type EnrichmentCacheRepo struct {
+	DB  *pgxpool.Pool
+	Log *slog.Logger
+}
+
+func (cr *EnrichmentCacheRepo) Get(name string) (*models.EnrichmentCacheEntry, error) {
+	query := "SELECT name, data, expires_at FROM name_enrichment_cache WHERE name = $1 AND expires_at > now()"
+	cr.Log.Debug("Query to DB", slog.String("Query", query), slog.String("name", name))
+
+	var e models.EnrichmentCacheEntry
+	err := cr.DB.QueryRow(context.Background(), query, name).Scan(&e.Name, &e.Result, &e.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (cr *EnrichmentCacheRepo) Put(entry *models.EnrichmentCacheEntry) error {
+	query := "INSERT INTO name_enrichment_cache (name, data, expires_at) VALUES($1,$2,$3) ON CONFLICT (name) DO UPDATE SET data = EXCLUDED.data, expires_at = EXCLUDED.expires_at"
+	cr.Log.Debug("Query to put cache entry", slog.String("Query", query), slog.String("name", entry.Name))
+
+	_, err := cr.DB.Exec(context.Background(), query, entry.Name, entry.Result, entry.ExpiresAt)
+	return err
+}
+
+// Delete удаляет запись для имени и записи для этого имени со странами (name:RU)
+func (cr *EnrichmentCacheRepo) Delete(name string) error {
+	query := "DELETE FROM name_enrichment_cache WHERE name = $1 OR starts_with(name, $1 || ':')"
+	cr.Log.Debug("Query to delete cache entry", slog.String("Query", query), slog.String("name", name))
+
+	_, err := cr.DB.Exec(context.Background(), query, name)
+	return err
+}
+
+func (cr *EnrichmentCacheRepo) DeleteAll() error {
+	query := "DELETE FROM name_enrichment_cache"
+	cr.Log.Debug("Query to delete all cache entries", slog.String("Query", query))
+
+	_, err := cr.DB.Exec(context.Background(), query)
+	return err
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"EfectiveMobile/internal/models"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type EnrichmentJobRepo struct {
+	DB  *pgxpool.Pool
+	Log *slog.Logger
+}
+
+func (jr *EnrichmentJobRepo) Enqueue(personID int, attributes []string, runAt time.Time) error {
+	query := "INSERT INTO enrichment_jobs (person_id, attributes, run_at) VALUES($1,$2,$3)"
+	jr.Log.Debug("Query to enqueue enrichment job", slog.String("Query", query), slog.Int("personid", personID))
+
+	_, err := jr.DB.Exec(context.Background(), query, personID, attributes, runAt)
+	return err
+}
+
+// Claim забирает одну готовую к выполнению задачу и блокирует её на lock; задачи,
+// заблокированные упавшим воркером, снова становятся доступны по истечении блокировки.
+// Если задач нет, возвращается nil
+func (jr *EnrichmentJobRepo) Claim(lock time.Duration) (*models.EnrichmentJob, error) {
+	query := `UPDATE enrichment_jobs SET locked_until = now() + make_interval(secs => $1), attempts = attempts + 1
+		WHERE id = (
+			SELECT id FROM enrichment_jobs
+			WHERE run_at <= now() AND (locked_until IS NULL OR locked_until < now())
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, person_id, attributes, attempts, run_at, COALESCE(last_error, '')`
+
+	var j models.EnrichmentJob
+	err := jr.DB.QueryRow(context.Background(), query, lock.Seconds()).Scan(&j.ID, &j.PersonID, &j.Attributes, &j.Attempts, &j.RunAt, &j.LastError)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	jr.Log.Debug("Claimed enrichment job", slog.Int("id", j.ID), slog.Int("personid", j.PersonID), slog.Int("attempt", j.Attempts))
+	return &j, nil
+}
+
+func (jr *EnrichmentJobRepo) Reschedule(id int, attributes []string, runAt time.Time, lastError string) error {
+	query := "UPDATE enrichment_jobs SET attributes = $1, run_at = $2, locked_until = NULL, last_error = $3 WHERE id = $4"
+	jr.Log.Debug("Query to reschedule enrichment job", slog.String("Query", query), slog.Int("id", id))
+
+	_, err := jr.DB.Exec(context.Background(), query, attributes, runAt, lastError, id)
+	return err
+}
+
+// Postpone откладывает задачу до runAt, не засчитывая текущую попытку
+func (jr *EnrichmentJobRepo) Postpone(id int, attributes []string, runAt time.Time, lastError string) error {
+	query := "UPDATE enrichment_jobs SET attributes = $1, run_at = $2, locked_until = NULL, last_error = $3, attempts = GREATEST(attempts - 1, 0) WHERE id = $4"
+	jr.Log.Debug("Query to postpone enrichment job", slog.String("Query", query), slog.Int("id", id))
+
+	_, err := jr.DB.Exec(context.Background(), query, attributes, runAt, lastError, id)
+	return err
+}
+
+func (jr *EnrichmentJobRepo) Delete(id int) error {
+	query := "DELETE FROM enrichment_jobs WHERE id = $1"
+	jr.Log.Debug("Query to delete enrichment job", slog.String("Query", query), slog.Int("id", id))
+
+	_, err := jr.DB.Exec(context.Background(), query, id)
+	return err
+}
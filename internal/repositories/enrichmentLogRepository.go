@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"EfectiveMobile/internal/models"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type EnrichmentLogRepo struct {
+	DB  *pgxpool.Pool
+	Log *slog.Logger
+}
+
+func (lr *EnrichmentLogRepo) Save(entries []models.EnrichmentLogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	query := "INSERT INTO enrichment_log (person_id, provider, url, status_code, body, latency_ms, error, created_at) VALUES($1,$2,$3,NULLIF($4, 0),NULLIF($5, ''),$6,NULLIF($7, ''),$8)"
+	lr.Log.Debug("Query to save enrichment log", slog.String("Query", query), slog.Int("entries", len(entries)))
+
+	batch := &pgx.Batch{}
+	for _, e := range entries {
+		batch.Queue(query, e.PersonID, e.Provider, e.URL, e.StatusCode, e.Body, e.LatencyMs, e.Error, e.CreatedAt)
+	}
+	return lr.DB.SendBatch(context.Background(), batch).Close()
+}
+
+// GetByPerson возвращает журнал обмена с провайдерами для человека, новые записи первыми
+func (lr *EnrichmentLogRepo) GetByPerson(personID, limit, offset int) ([]models.EnrichmentLogEntry, error) {
+	query := "SELECT id, person_id, provider, url, COALESCE(status_code, 0), COALESCE(body, ''), latency_ms, COALESCE(error, ''), created_at FROM enrichment_log WHERE person_id = $1 ORDER BY created_at DESC, id DESC LIMIT NULLIF($2, 0) OFFSET $3"
+	lr.Log.Debug("Query to get enrichment log", slog.String("Query", query), slog.Int("personid", personID))
+
+	rows, err := lr.DB.Query(context.Background(), query, personID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.EnrichmentLogEntry{}
+	for rows.Next() {
+		var e models.EnrichmentLogEntry
+		if err := rows.Scan(&e.ID, &e.PersonID, &e.Provider, &e.URL, &e.StatusCode, &e.Body, &e.LatencyMs, &e.Error, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// DeleteOlderThan удаляет записи журнала, созданные раньше before, и возвращает их количество
+func (lr *EnrichmentLogRepo) DeleteOlderThan(before time.Time) (int64, error) {
+	query := "DELETE FROM enrichment_log WHERE created_at < $1"
+	lr.Log.Debug("Query to delete old enrichment log", slog.String("Query", query), slog.Time("before", before))
+
+	tag, err := lr.DB.Exec(context.Background(), query, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,237 @@
+package repositories
+
+import (
+	"EfectiveMobile/internal/filter"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// personColumn - SQL-выражение поля и допустимые для него операторы. Значения числовых
+// полей приводятся к float8, чтобы сравнивать целые колонки с дробными значениями
+type personColumn struct {
+	sql       string
+	operators []filter.Operator
+	cast      string
+	// caseInsensitive - сравнивать строки без учёта регистра
+	caseInsensitive bool
+}
+
+var (
+	textOperators     = []filter.Operator{filter.Eq, filter.Ne, filter.In, filter.NotIn, filter.Prefix, filter.Contains, filter.EqualFold}
+	nullableOperators = append(slices.Clone(textOperators), filter.IsNull, filter.NotNull)
+	measureOperators  = []filter.Operator{filter.Eq, filter.Ne, filter.Lt, filter.Gt, filter.Lte, filter.Gte, filter.Between}
+	numberOperators   = append(slices.Clone(measureOperators), filter.In, filter.NotIn, filter.IsNull, filter.NotNull)
+)
+
+// personColumns - белый список полей фильтрации; в SQL попадают только эти выражения
+var personColumns = map[filter.Field]personColumn{
+	filter.Name:                   {sql: "name", operators: textOperators},
+	filter.Surname:                {sql: "surname", operators: textOperators},
+	filter.Patronymic:             {sql: "patronymic", operators: nullableOperators},
+	filter.NameTranslit:           {sql: "name_translit", operators: textOperators, caseInsensitive: true},
+	filter.SurnameTranslit:        {sql: "surname_translit", operators: textOperators, caseInsensitive: true},
+	filter.PatronymicTranslit:     {sql: "patronymic_translit", operators: textOperators, caseInsensitive: true},
+	filter.Age:                    {sql: "age", operators: numberOperators},
+	filter.AgeCount:               {sql: "COALESCE(age_count, 0)", operators: measureOperators, cast: "::float8"},
+	filter.Gender:                 {sql: "gender", operators: nullableOperators},
+	filter.GenderProbability:      {sql: "COALESCE(gender_probability, 0)", operators: measureOperators, cast: "::float8"},
+	filter.GenderCount:            {sql: "COALESCE(gender_count, 0)", operators: measureOperators, cast: "::float8"},
+	filter.Nationality:            {sql: "nationality", operators: append(slices.Clone(nullableOperators), filter.Any)},
+	filter.NationalityProbability: {sql: "COALESCE(nationality_probability, 0)", operators: measureOperators, cast: "::float8"},
+}
+
+// sortColumns - белый список полей сортировки
+var sortColumns = map[filter.Field]string{
+	filter.ID:                     "personid",
+	filter.Name:                   "name",
+	filter.Surname:                "surname",
+	filter.Patronymic:             "patronymic",
+	filter.Age:                    "age",
+	filter.AgeCount:               "age_count",
+	filter.Gender:                 "gender",
+	filter.GenderProbability:      "gender_probability",
+	filter.GenderCount:            "gender_count",
+	filter.Nationality:            "nationality",
+	filter.NationalityProbability: "nationality_probability",
+}
+
+var comparisons = map[filter.Operator]string{
+	filter.Eq:  "=",
+	filter.Ne:  "!=",
+	filter.Lt:  "<",
+	filter.Gt:  ">",
+	filter.Lte: "<=",
+	filter.Gte: ">=",
+}
+
+// likeEscaper экранирует символы шаблонов LIKE, чтобы значение сравнивалось буквально
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// filterCompiler переводит дерево условий в SQL, складывая значения в args
+type filterCompiler struct {
+	args []any
+}
+
+func (c *filterCompiler) placeholder(value any) string {
+	c.args = append(c.args, value)
+	return "$" + strconv.Itoa(len(c.args))
+}
+
+func (c *filterCompiler) compile(expr filter.Expr) (string, error) {
+	switch e := expr.(type) {
+	case nil:
+		return "TRUE", nil
+	case filter.And:
+		return c.join(e, "AND", "TRUE")
+	case filter.Or:
+		return c.join(e, "OR", "FALSE")
+	case filter.Not:
+		part, err := c.compile(e.Expr)
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + part + ")", nil
+	case filter.Condition:
+		return c.condition(e)
+	default:
+		return "", fmt.Errorf("unsupported filter expression %T", expr)
+	}
+}
+
+// join соединяет условия оператором; empty - значение для пустого списка условий
+func (c *filterCompiler) join(exprs []filter.Expr, operator, empty string) (string, error) {
+	if len(exprs) == 0 {
+		return empty, nil
+	}
+	parts := make([]string, len(exprs))
+	for i, sub := range exprs {
+		part, err := c.compile(sub)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = part
+	}
+	return "(" + strings.Join(parts, " "+operator+" ") + ")", nil
+}
+
+func (c *filterCompiler) condition(cond filter.Condition) (string, error) {
+	column, ok := personColumns[cond.Field]
+	if !ok {
+		return "", fmt.Errorf("unsupported filter field %q", cond.Field)
+	}
+	if !slices.Contains(column.operators, cond.Operator) {
+		return "", fmt.Errorf("unsupported operator %q for field %q", cond.Operator, cond.Field)
+	}
+
+	switch cond.Operator {
+	case filter.IsNull:
+		return column.sql + " IS NULL", nil
+	case filter.NotNull:
+		return column.sql + " IS NOT NULL", nil
+	}
+	if cond.Value == nil {
+		return "", fmt.Errorf("missing value for field %q", cond.Field)
+	}
+
+	switch cond.Operator {
+	case filter.Any:
+		value := c.placeholder(cond.Value)
+		return fmt.Sprintf("(%[1]s = %[2]s OR EXISTS (SELECT 1 FROM person_nationalities pn WHERE pn.person_id = persons.personid AND pn.country_id = %[2]s))", column.sql, value), nil
+	case filter.In, filter.NotIn:
+		values, ok := cond.Value.([]any)
+		if !ok || len(values) == 0 {
+			return "", fmt.Errorf("operator %q for field %q needs a list of values", cond.Operator, cond.Field)
+		}
+		placeholders := make([]string, len(values))
+		for i, v := range values {
+			placeholders[i] = c.value(column, v)
+		}
+		sqlOperator := "IN"
+		if cond.Operator == filter.NotIn {
+			sqlOperator = "NOT IN"
+		}
+		return fmt.Sprintf("%s %s (%s)", c.column(column), sqlOperator, strings.Join(placeholders, ", ")), nil
+	case filter.Between:
+		bounds, ok := cond.Value.([]any)
+		if !ok || len(bounds) != 2 {
+			return "", fmt.Errorf("operator %q for field %q needs two values", cond.Operator, cond.Field)
+		}
+		return fmt.Sprintf("%s BETWEEN %s AND %s", c.column(column), c.value(column, bounds[0]), c.value(column, bounds[1])), nil
+	case filter.Prefix, filter.Contains, filter.EqualFold:
+		value, ok := cond.Value.(string)
+		if !ok {
+			return "", fmt.Errorf("operator %q for field %q needs a string value", cond.Operator, cond.Field)
+		}
+		pattern := likeEscaper.Replace(value)
+		switch cond.Operator {
+		case filter.Prefix:
+			pattern += "%"
+		case filter.Contains:
+			pattern = "%" + pattern + "%"
+		}
+		sqlOperator := "LIKE"
+		if column.caseInsensitive || cond.Operator == filter.EqualFold {
+			sqlOperator = "ILIKE"
+		}
+		return fmt.Sprintf("%s %s %s", column.sql, sqlOperator, c.placeholder(pattern)), nil
+	}
+	return fmt.Sprintf("%s %s %s", c.column(column), comparisons[cond.Operator], c.value(column, cond.Value)), nil
+}
+
+// column возвращает SQL-выражение колонки для сравнения, value - плейсхолдер значения;
+// для регистронезависимых колонок обе стороны приводятся к нижнему регистру
+func (c *filterCompiler) column(column personColumn) string {
+	if column.caseInsensitive {
+		return "lower(" + column.sql + ")"
+	}
+	return column.sql
+}
+
+func (c *filterCompiler) value(column personColumn, value any) string {
+	placeholder := c.placeholder(value) + column.cast
+	if column.caseInsensitive {
+		return "lower(" + placeholder + ")"
+	}
+	return placeholder
+}
+
+// compileQuery возвращает условия, сортировку и пагинацию запроса в виде SQL после WHERE и аргументы к нему.
+// Сортировка всегда заканчивается personid, чтобы страницы limit и offset не зависели от плана запроса;
+// поля после id ничего не меняют и отбрасываются
+func compileQuery(q filter.Query) (string, []any, error) {
+	c := &filterCompiler{}
+	where, err := c.compile(q.Where)
+	if err != nil {
+		return "", nil, err
+	}
+	order := []string{}
+	tiebreaker := true
+	for _, sort := range q.Sort {
+		column, ok := sortColumns[sort.Field]
+		if !ok {
+			return "", nil, fmt.Errorf("unsupported sort field %q", sort.Field)
+		}
+		direction := "ASC"
+		if sort.Desc {
+			direction = "DESC"
+		}
+		order = append(order, column+" "+direction+" NULLS LAST")
+		if sort.Field == filter.ID {
+			tiebreaker = false
+			break
+		}
+	}
+	if tiebreaker {
+		order = append(order, "personid ASC")
+	}
+	where += " ORDER BY " + strings.Join(order, ", ")
+	if q.Limit != 0 {
+		where += " LIMIT " + c.placeholder(q.Limit)
+	}
+	if q.Offset != 0 {
+		where += " OFFSET " + c.placeholder(q.Offset)
+	}
+	return where, c.args, nil
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"EfectiveMobile/internal/filter"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// fuzzQuery - запрос фиксированной структуры, в котором от входа зависят только значения
+func fuzzQuery(text string, number float64, limit, offset int) filter.Query {
+	return filter.Query{
+		Where: filter.And{
+			filter.Condition{Field: filter.Name, Operator: filter.Eq, Value: text},
+			filter.Condition{Field: filter.NameTranslit, Operator: filter.Eq, Value: text},
+			filter.Or{
+				filter.Condition{Field: filter.Surname, Operator: filter.Prefix, Value: text},
+				filter.Condition{Field: filter.Patronymic, Operator: filter.Contains, Value: text},
+				filter.Condition{Field: filter.Gender, Operator: filter.EqualFold, Value: text},
+			},
+			filter.Not{Expr: filter.Condition{Field: filter.Nationality, Operator: filter.Any, Value: text}},
+			filter.Condition{Field: filter.Surname, Operator: filter.NotIn, Value: []any{text, text}},
+			filter.Condition{Field: filter.Age, Operator: filter.Between, Value: []any{number, number}},
+			filter.Condition{Field: filter.GenderProbability, Operator: filter.Gte, Value: number},
+			filter.Condition{Field: filter.Patronymic, Operator: filter.IsNull},
+		},
+		Sort:   []filter.Sort{{Field: filter.Age, Desc: true}, {Field: filter.Name}},
+		Limit:  limit,
+		Offset: offset,
+	}
+}
+
+// FuzzCompileQuery проверяет, что текст SQL зависит только от полей и операторов,
+// а все значения попадают в аргументы
+func FuzzCompileQuery(f *testing.F) {
+	f.Add("Dmitriy", 42.0, 10, 0)
+	f.Add("'; DROP TABLE persons; --", -1.5, 0, 20)
+	f.Add(`100%_\`, 0.0, 1, 1)
+	f.Add("$1) OR (TRUE", 1e300, -1, -1)
+	f.Fuzz(func(t *testing.T, text string, number float64, limit, offset int) {
+		sql, args, err := compileQuery(fuzzQuery(text, number, limit, offset))
+		if err != nil {
+			t.Fatalf("compileQuery: %v", err)
+		}
+
+		benignSQL, _, err := compileQuery(fuzzQuery("x", 1, min(max(limit, -1), 1), min(max(offset, -1), 1)))
+		if err != nil {
+			t.Fatalf("compileQuery: %v", err)
+		}
+		if sql != benignSQL {
+			t.Errorf("SQL depends on values:\n got: %s\nwant: %s", sql, benignSQL)
+		}
+
+		pattern := likeEscaper.Replace(text)
+		want := []any{text, text, pattern + "%", "%" + pattern + "%", pattern, text, text, text, number, number, number}
+		if limit != 0 {
+			want = append(want, limit)
+		}
+		if offset != 0 {
+			want = append(want, offset)
+		}
+		if !sameArgs(args, want) {
+			t.Errorf("args = %#v; want %#v", args, want)
+		}
+		for i := range args {
+			if !strings.Contains(sql, fmt.Sprintf("$%d", i+1)) {
+				t.Errorf("SQL has no placeholder for arg %d: %s", i+1, sql)
+			}
+		}
+		if extra := fmt.Sprintf("$%d", len(args)+1); strings.Contains(sql, extra) {
+			t.Errorf("SQL has placeholder %s without an arg: %s", extra, sql)
+		}
+	})
+}
+
+// sameArgs сравнивает аргументы, считая NaN равным NaN
+func sameArgs(got, want []any) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		g, gok := got[i].(float64)
+		w, wok := want[i].(float64)
+		if gok && wok && math.IsNaN(g) && math.IsNaN(w) {
+			continue
+		}
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -1,38 +1,48 @@
 package repositories
 
 import (
+	"EfectiveMobile/internal/filter"
 	"EfectiveMobile/internal/models"
 	"context"
 	"log/slog"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type PersonRepo struct {
-	DB  *pgx.Conn
+	DB  *pgxpool.Pool
 	Log *slog.Logger
 }
 
 func (pr *PersonRepo) GetPersonByID(id int, p *models.Person) (*models.Person, error) {
-	query := "SELECT name, surname, COALESCE(patronymic, ''), age, gender, nationality FROM persons WHERE personid = $1"
+	query := "SELECT name, surname, COALESCE(patronymic, ''), COALESCE(name_translit, ''), COALESCE(surname_translit, ''), COALESCE(patronymic_translit, ''), COALESCE(country_hint, ''), age, COALESCE(age_count, 0), gender, COALESCE(gender_probability, 0), COALESCE(gender_count, 0), nationality, COALESCE(nationality_probability, 0), enrichment_status, provenance FROM persons WHERE personid = $1"
 	pr.Log.Debug("Query to DB", slog.String("Query", query), slog.Int("personid", id))
 
-	err := pr.DB.QueryRow(context.Background(), query, id).Scan(&p.Name, &p.Surname, &p.Patronymic, &p.Age, &p.Gender, &p.Nationality)
+	err := pr.DB.QueryRow(context.Background(), query, id).Scan(&p.Name, &p.Surname, &p.Patronymic, &p.NameTranslit, &p.SurnameTranslit, &p.PatronymicTranslit, &p.CountryHint, &p.Age, &p.AgeCount, &p.Gender, &p.GenderProbability, &p.GenderCount, &p.Nationality, &p.NationalityProbability, &p.EnrichmentStatus, &p.Provenance)
 	if err != nil {
 		return nil, err
 	}
+
+	nationalities, err := pr.getNationalities([]int{id})
+	if err != nil {
+		return nil, err
+	}
+	p.Nationalities = nationalities[id]
+
 	pr.Log.Debug("Returning person", slog.Any("person", p))
 	return p, err
 }
 
-func (pr *PersonRepo) GetPersonsByParams(filter string) ([]models.Person, error) {
-	query := "SELECT personid, name, surname, COALESCE(patronymic, ''), age, gender, nationality FROM persons WHERE 1=1 "
-	if len(filter) > 0 {
-		query = query + filter
+func (pr *PersonRepo) GetPersonsByParams(q filter.Query) ([]models.Person, error) {
+	where, args, err := compileQuery(q)
+	if err != nil {
+		return nil, err
 	}
-	pr.Log.Debug("Query to DB with filter", slog.String("Query", query), slog.String("filter", filter))
+	query := "SELECT personid, name, surname, COALESCE(patronymic, ''), COALESCE(name_translit, ''), COALESCE(surname_translit, ''), COALESCE(patronymic_translit, ''), COALESCE(country_hint, ''), age, COALESCE(age_count, 0), gender, COALESCE(gender_probability, 0), COALESCE(gender_count, 0), nationality, COALESCE(nationality_probability, 0), enrichment_status, provenance FROM persons WHERE " + where
+	pr.Log.Debug("Query to DB with filter", slog.String("Query", query), slog.Any("args", args))
 
-	rows, err := pr.DB.Query(context.Background(), query)
+	rows, err := pr.DB.Query(context.Background(), query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -41,25 +51,53 @@ func (pr *PersonRepo) GetPersonsByParams(filter string) ([]models.Person, error)
 	persons := []models.Person{}
 	for rows.Next() {
 		var p models.Person
-		if err := rows.Scan(&p.ID, &p.Name, &p.Surname, &p.Patronymic, &p.Age, &p.Gender, &p.Nationality); err != nil {
+		if err := rows.Scan(&p.ID, &p.Name, &p.Surname, &p.Patronymic, &p.NameTranslit, &p.SurnameTranslit, &p.PatronymicTranslit, &p.CountryHint, &p.Age, &p.AgeCount, &p.Gender, &p.GenderProbability, &p.GenderCount, &p.Nationality, &p.NationalityProbability, &p.EnrichmentStatus, &p.Provenance); err != nil {
 			return nil, err
 		}
 		pr.Log.Debug("Add person to returning", slog.Any("person", p))
 		persons = append(persons, p)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	ids := make([]int, 0, len(persons))
+	for _, p := range persons {
+		ids = append(ids, p.ID)
+	}
+	nationalities, err := pr.getNationalities(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range persons {
+		persons[i].Nationalities = nationalities[persons[i].ID]
+	}
 
 	return persons, nil
 
 }
 
 func (pr *PersonRepo) CreatePerson(person *models.Person) (int, error) {
-	query := "INSERT INTO persons (name, surname, patronymic, age, gender, nationality) VALUES($1,$2,NULLIF($3, ''),$4,$5,$6) returning personid"
+	tx, err := pr.DB.Begin(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(context.Background())
+
+	query := "INSERT INTO persons (name, surname, patronymic, name_translit, surname_translit, patronymic_translit, country_hint, age, age_count, gender, gender_probability, gender_count, nationality, nationality_probability, enrichment_status, provenance) VALUES($1,$2,NULLIF($3, ''),$4,$5,NULLIF($6, ''),NULLIF($7, ''),$8,$9,$10,$11,$12,$13,$14,COALESCE(NULLIF($15, ''), 'done'),$16) returning personid"
 	pr.Log.Debug("Query to create person", slog.String("Query", query))
 	var id int
-	err := pr.DB.QueryRow(context.Background(), query, person.Name, person.Surname, person.Patronymic, person.Age, person.Gender, person.Nationality).Scan(&id)
+	err = tx.QueryRow(context.Background(), query, person.Name, person.Surname, person.Patronymic, person.NameTranslit, person.SurnameTranslit, person.PatronymicTranslit, person.CountryHint, person.Age, person.AgeCount, person.Gender, person.GenderProbability, person.GenderCount, person.Nationality, person.NationalityProbability, person.EnrichmentStatus, provenance(person)).Scan(&id)
 	if err != nil {
 		return 0, err
 	}
+	if err := pr.saveNationalities(tx, id, person.Nationalities); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(context.Background()); err != nil {
+		return 0, err
+	}
 	pr.Log.Debug("Succesful created person", slog.Any("person data", person))
 	return id, nil
 }
@@ -76,12 +114,138 @@ func (pr *PersonRepo) DeletePersonById(id int) error {
 }
 
 func (pr *PersonRepo) UpdatePerson(person *models.Person) error {
-	query := "UPDATE persons SET name = $1, surname = $2, patronymic = $3, age = $4, gender = $5, nationality = $6 WHERE personId = $7"
-	pr.Log.Debug("Query to delete person", slog.String("Query", query))
-	_, err := pr.DB.Exec(context.Background(), query, person.Name, person.Surname, person.Patronymic, person.Age, person.Gender, person.Nationality, person.ID)
+	tx, err := pr.DB.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	query := "UPDATE persons SET name = $1, surname = $2, patronymic = $3, name_translit = $4, surname_translit = $5, patronymic_translit = NULLIF($6, ''), age = $7, age_count = $8, gender = $9, gender_probability = $10, gender_count = $11, nationality = $12, nationality_probability = $13, enrichment_status = COALESCE(NULLIF($14, ''), enrichment_status), provenance = $15 WHERE personId = $16"
+	pr.Log.Debug("Query to update person", slog.String("Query", query))
+	_, err = tx.Exec(context.Background(), query, person.Name, person.Surname, person.Patronymic, person.NameTranslit, person.SurnameTranslit, person.PatronymicTranslit, person.Age, person.AgeCount, person.Gender, person.GenderProbability, person.GenderCount, person.Nationality, person.NationalityProbability, person.EnrichmentStatus, provenance(person), person.ID)
 	if err != nil {
 		return err
 	}
+	if person.Nationalities != nil {
+		if err := pr.saveNationalities(tx, person.ID, person.Nationalities); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(context.Background()); err != nil {
+		return err
+	}
 	pr.Log.Debug("Succesful update person", slog.Any("person data", person))
 	return nil
 }
+
+// SaveEnrichment сохраняет результат обогащения. В отличие от UpdatePerson, поля, которые
+// пользователь исправил вручную, не перезаписываются, даже если это произошло уже после того,
+// как person был прочитан
+func (pr *PersonRepo) SaveEnrichment(person *models.Person) error {
+	tx, err := pr.DB.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	query := `UPDATE persons SET
+		age = CASE WHEN provenance->'age'->>'source' = 'manual' THEN age ELSE $1 END,
+		age_count = CASE WHEN provenance->'age'->>'source' = 'manual' THEN age_count ELSE $2 END,
+		gender = CASE WHEN provenance->'gender'->>'source' = 'manual' THEN gender ELSE $3 END,
+		gender_probability = CASE WHEN provenance->'gender'->>'source' = 'manual' THEN gender_probability ELSE $4 END,
+		gender_count = CASE WHEN provenance->'gender'->>'source' = 'manual' THEN gender_count ELSE $5 END,
+		nationality = CASE WHEN provenance->'nationality'->>'source' = 'manual' THEN nationality ELSE $6 END,
+		nationality_probability = CASE WHEN provenance->'nationality'->>'source' = 'manual' THEN nationality_probability ELSE $7 END,
+		enrichment_status = COALESCE(NULLIF($8, ''), enrichment_status),
+		provenance = provenance || $9::jsonb || (SELECT COALESCE(jsonb_object_agg(key, value), '{}') FROM jsonb_each(provenance) WHERE value->>'source' = 'manual')
+		WHERE personId = $10
+		RETURNING COALESCE(provenance->'nationality'->>'source' = 'manual', false)`
+	pr.Log.Debug("Query to save person enrichment", slog.String("Query", query))
+	var nationalityManual bool
+	err = tx.QueryRow(context.Background(), query, person.Age, person.AgeCount, person.Gender, person.GenderProbability, person.GenderCount, person.Nationality, person.NationalityProbability, person.EnrichmentStatus, enrichedProvenance(person), person.ID).Scan(&nationalityManual)
+	if err != nil {
+		return err
+	}
+	if person.Nationalities != nil && !nationalityManual {
+		if err := pr.saveNationalities(tx, person.ID, person.Nationalities); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(context.Background()); err != nil {
+		return err
+	}
+	pr.Log.Debug("Succesful saved person enrichment", slog.Any("person data", person))
+	return nil
+}
+
+func (pr *PersonRepo) SetEnrichmentStatus(id int, status string) error {
+	query := "UPDATE persons SET enrichment_status = $1 WHERE personId = $2"
+	pr.Log.Debug("Query to set enrichment status", slog.String("Query", query), slog.Int("personid", id), slog.String("status", status))
+	_, err := pr.DB.Exec(context.Background(), query, status, id)
+	return err
+}
+
+func (pr *PersonRepo) saveNationalities(tx pgx.Tx, id int, nationalities []models.PersonNationality) error {
+	query := "DELETE FROM person_nationalities WHERE person_id = $1"
+	pr.Log.Debug("Query to delete person nationalities", slog.String("Query", query), slog.Int("personid", id))
+	if _, err := tx.Exec(context.Background(), query, id); err != nil {
+		return err
+	}
+
+	query = "INSERT INTO person_nationalities (person_id, country_id, probability, rank) VALUES($1,$2,$3,$4)"
+	for _, n := range nationalities {
+		pr.Log.Debug("Query to create person nationality", slog.String("Query", query), slog.Any("nationality", n))
+		if _, err := tx.Exec(context.Background(), query, id, n.CountryID, n.Probability, n.Rank); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (pr *PersonRepo) getNationalities(ids []int) (map[int][]models.PersonNationality, error) {
+	query := "SELECT person_id, country_id, probability, rank FROM person_nationalities WHERE person_id = ANY($1) ORDER BY person_id, rank"
+	pr.Log.Debug("Query to get person nationalities", slog.String("Query", query), slog.Any("personids", ids))
+
+	result := map[int][]models.PersonNationality{}
+	for _, id := range ids {
+		result[id] = []models.PersonNationality{}
+	}
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	rows, err := pr.DB.Query(context.Background(), query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var n models.PersonNationality
+		if err := rows.Scan(&id, &n.CountryID, &n.Probability, &n.Rank); err != nil {
+			return nil, err
+		}
+		result[id] = append(result[id], n)
+	}
+	return result, rows.Err()
+}
+
+func provenance(person *models.Person) map[string]models.FieldProvenance {
+	if person.Provenance == nil {
+		return map[string]models.FieldProvenance{}
+	}
+	return person.Provenance
+}
+
+// enrichedProvenance возвращает только отметки обогащения: ручные отметки в SaveEnrichment
+// всегда берутся из базы
+func enrichedProvenance(person *models.Person) map[string]models.FieldProvenance {
+	result := map[string]models.FieldProvenance{}
+	for field, p := range person.Provenance {
+		if p.Source != models.SourceManual {
+			result[field] = p
+		}
+	}
+	return result
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"EfectiveMobile/internal/enrichers"
+	"EfectiveMobile/internal/models"
+	"EfectiveMobile/internal/repositories"
+	"EfectiveMobile/pkg/lru"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// EnrichmentCache хранит результаты обогащения по нормализованному имени и стране запроса:
+// LRU в памяти перед таблицей name_enrichment_cache
+type EnrichmentCache struct {
+	Repo *repositories.EnrichmentCacheRepo
+	Log  *slog.Logger
+	TTL  time.Duration
+
+	memory     *lru.Cache[string, models.EnrichmentCacheEntry]
+	hits       atomic.Int64
+	misses     atomic.Int64
+	memoryHits atomic.Int64
+	dbHits     atomic.Int64
+}
+
+func NewEnrichmentCache(repo *repositories.EnrichmentCacheRepo, log *slog.Logger, size int, ttl time.Duration) *EnrichmentCache {
+	return &EnrichmentCache{Repo: repo, Log: log, TTL: ttl, memory: lru.New[string, models.EnrichmentCacheEntry](size)}
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// cacheKey - ключ кэша: нормализованное имя, а для запроса со страной - имя и страна (ivan:RU)
+func cacheKey(q enrichers.Query) string {
+	if q.CountryID == "" {
+		return normalizeName(q.Name)
+	}
+	return normalizeName(q.Name) + ":" + strings.ToUpper(q.CountryID)
+}
+
+func (c *EnrichmentCache) Get(q enrichers.Query) (*enrichers.Result, bool) {
+	key := cacheKey(q)
+
+	if entry, ok := c.memory.Get(key); ok {
+		if time.Now().Before(entry.ExpiresAt) {
+			c.hits.Add(1)
+			c.memoryHits.Add(1)
+			return &entry.Result, true
+		}
+		c.memory.Remove(key)
+	}
+
+	entry, err := c.Repo.Get(key)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			c.Log.Error("Cannot read enrichment cache", slog.String("name", key), slog.String("error", err.Error()))
+		}
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.memory.Add(key, *entry)
+	c.hits.Add(1)
+	c.dbHits.Add(1)
+	return &entry.Result, true
+}
+
+func (c *EnrichmentCache) Put(q enrichers.Query, result enrichers.Result) {
+	entry := models.EnrichmentCacheEntry{Name: cacheKey(q), Result: result, ExpiresAt: time.Now().Add(c.TTL)}
+
+	c.memory.Add(entry.Name, entry)
+	if err := c.Repo.Put(&entry); err != nil {
+		c.Log.Error("Cannot write enrichment cache", slog.String("name", entry.Name), slog.String("error", err.Error()))
+	}
+}
+
+// Invalidate удаляет записи для имени запроса, в том числе записи для всех стран
+func (c *EnrichmentCache) Invalidate(q enrichers.Query) error {
+	key := cacheKey(enrichers.Query{Name: q.Name})
+	c.memory.RemoveFunc(func(k string) bool { return k == key || strings.HasPrefix(k, key+":") })
+	return c.Repo.Delete(key)
+}
+
+// InvalidateName удаляет из кэша записи для имени. Записи хранятся по транслитерированному имени,
+// поэтому имя приводится к ключу так же, как имя человека при обогащении
+func (ps *PersonService) InvalidateName(name string) error {
+	if ps.Cache == nil {
+		return nil
+	}
+	person := &models.Person{Name: name}
+	ps.transliterate(person)
+	return ps.Cache.Invalidate(personQuery(person))
+}
+
+func (c *EnrichmentCache) InvalidateAll() error {
+	c.memory.Purge()
+	return c.Repo.DeleteAll()
+}
+
+func (c *EnrichmentCache) Stats() models.EnrichmentCacheStats {
+	return models.EnrichmentCacheStats{
+		Hits:       c.hits.Load(),
+		Misses:     c.misses.Load(),
+		MemoryHits: c.memoryHits.Load(),
+		DBHits:     c.dbHits.Load(),
+		Size:       c.memory.Len(),
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"EfectiveMobile/internal/enrichers"
+	"EfectiveMobile/internal/models"
+	"EfectiveMobile/internal/repositories"
+	"context"
+	"log/slog"
+	"time"
+)
+
+const defaultLogCleanupInterval = time.Hour
+
+// recording возвращает контекст, в котором запросы к провайдерам записываются в журнал.
+// Если журнал выключен, recorder равен nil
+func (ps *PersonService) recording(ctx context.Context) (context.Context, *enrichers.Recorder) {
+	if ps.EnrichmentLog == nil {
+		return ctx, nil
+	}
+	recorder := &enrichers.Recorder{}
+	return enrichers.WithRecorder(ctx, recorder), recorder
+}
+
+// archive сохраняет в журнал запросы, в которых было имя человека. Ошибка записи журнала
+// не должна ломать обогащение, поэтому только логируется
+func (ps *PersonService) archive(person *models.Person, recorder *enrichers.Recorder) {
+	if recorder == nil || person.ID == 0 {
+		return
+	}
+
+	exchanges := recorder.For(personQuery(person).Name)
+	entries := make([]models.EnrichmentLogEntry, 0, len(exchanges))
+	for _, e := range exchanges {
+		entries = append(entries, models.EnrichmentLogEntry{
+			PersonID:   person.ID,
+			Provider:   e.Provider,
+			URL:        e.URL,
+			StatusCode: e.StatusCode,
+			Body:       e.Body,
+			LatencyMs:  e.Latency.Milliseconds(),
+			Error:      e.Error,
+			CreatedAt:  e.At,
+		})
+	}
+	if err := ps.EnrichmentLog.Save(entries); err != nil {
+		ps.Log.Error("Cannot save enrichment log", slog.Int("id", person.ID), slog.String("error", err.Error()))
+	}
+}
+
+func (ps *PersonService) GetEnrichmentLog(id, limit, offset int) ([]models.EnrichmentLogEntry, error) {
+	if _, err := ps.GetPersonsByID(id); err != nil {
+		return nil, err
+	}
+	if ps.EnrichmentLog == nil {
+		return []models.EnrichmentLogEntry{}, nil
+	}
+	return ps.EnrichmentLog.GetByPerson(id, limit, offset)
+}
+
+// EnrichmentLogCleaner периодически удаляет записи журнала обмена с провайдерами старше Retention
+type EnrichmentLogCleaner struct {
+	Repo      *repositories.EnrichmentLogRepo
+	Log       *slog.Logger
+	Retention time.Duration
+	Interval  time.Duration
+}
+
+func (lc *EnrichmentLogCleaner) Run(ctx context.Context) {
+	if lc.Retention <= 0 {
+		lc.Log.Info("Enrichment log retention is disabled, records are kept forever")
+		return
+	}
+	interval := lc.Interval
+	if interval <= 0 {
+		interval = defaultLogCleanupInterval
+	}
+
+	go func() {
+		for {
+			deleted, err := lc.Repo.DeleteOlderThan(time.Now().Add(-lc.Retention))
+			if err != nil {
+				lc.Log.Error("Cannot clean up enrichment log", slog.String("error", err.Error()))
+			} else if deleted > 0 {
+				lc.Log.Info("Enrichment log cleaned up", slog.Int64("deleted", deleted))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"EfectiveMobile/internal/models"
+	"EfectiveMobile/internal/repositories"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	defaultWorkers      = 4
+	defaultPollInterval = time.Second
+	maxRetryDelay       = 24 * time.Hour
+)
+
+// EnrichmentWorkers выполняют задачи из таблицы enrichment_jobs: обогащают человека,
+// повторяют неудачные попытки с растущей задержкой и выставляют статус done или failed
+type EnrichmentWorkers struct {
+	PersonService *PersonService
+	Jobs          *repositories.EnrichmentJobRepo
+	Log           *slog.Logger
+	Workers       int
+	PollInterval  time.Duration
+}
+
+func (ew *EnrichmentWorkers) Run(ctx context.Context) {
+	workers := ew.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	for range workers {
+		go ew.work(ctx)
+	}
+	ew.Log.Info("Enrichment workers started", slog.Int("workers", workers))
+}
+
+func (ew *EnrichmentWorkers) work(ctx context.Context) {
+	poll := ew.PollInterval
+	if poll <= 0 {
+		poll = defaultPollInterval
+	}
+
+	for ctx.Err() == nil {
+		job, err := ew.Jobs.Claim(ew.lockTimeout())
+		if err != nil {
+			ew.Log.Error("Cannot claim enrichment job", slog.String("error", err.Error()))
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(poll):
+			}
+			continue
+		}
+		ew.process(ctx, job)
+	}
+}
+
+func (ew *EnrichmentWorkers) process(ctx context.Context, job *models.EnrichmentJob) {
+	ps := ew.PersonService
+	log := ew.Log.With(slog.Int("job", job.ID), slog.Int("personid", job.PersonID), slog.Int("attempt", job.Attempts))
+
+	person, err := ps.GetPersonsByID(job.PersonID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Debug("Person of enrichment job no longer exists")
+		ew.delete(job)
+		return
+	}
+	if err != nil {
+		log.Error("Cannot get person to enrich", slog.String("error", err.Error()))
+		return
+	}
+
+	ctx, recorder := ps.recording(ctx)
+	errs := ps.enrich(ctx, person, job.Attributes)
+	ps.archive(person, recorder)
+	remaining := []string{}
+	messages := []string{}
+	for _, attribute := range job.Attributes {
+		if err, ok := errs[attribute]; ok {
+			remaining = append(remaining, attribute)
+			messages = append(messages, attribute+": "+err.Error())
+		}
+	}
+
+	// неудача только из-за квоты не засчитывается как попытка: задача ждёт сброса квоты
+	resetAt, quotaOnly := ps.quotaRetry(errs, remaining)
+	switch {
+	case len(remaining) == 0:
+		person.EnrichmentStatus = models.EnrichmentDone
+	case quotaOnly:
+		person.EnrichmentStatus = models.EnrichmentPending
+	case job.Attempts >= ps.retryAttempts():
+		person.EnrichmentStatus = models.EnrichmentFailed
+		log.Warn("Enrichment retries exhausted", slog.Any("missing", remaining))
+	default:
+		person.EnrichmentStatus = models.EnrichmentPending
+	}
+	if err := ps.PersonRepo.SaveEnrichment(person); err != nil {
+		log.Error("Cannot update enriched person", slog.String("error", err.Error()))
+		return
+	}
+
+	if person.EnrichmentStatus != models.EnrichmentPending {
+		ew.delete(job)
+		return
+	}
+	if quotaOnly {
+		if err := ew.Jobs.Postpone(job.ID, remaining, resetAt, strings.Join(messages, "; ")); err != nil {
+			log.Error("Cannot postpone enrichment job", slog.String("error", err.Error()))
+			return
+		}
+		log.Debug("Enrichment job postponed until quota reset", slog.Any("missing", remaining), slog.Time("run_at", resetAt))
+		return
+	}
+	delay := min(ps.retryDelay()<<min(job.Attempts-1, 16), maxRetryDelay)
+	if err := ew.Jobs.Reschedule(job.ID, remaining, time.Now().Add(delay), strings.Join(messages, "; ")); err != nil {
+		log.Error("Cannot reschedule enrichment job", slog.String("error", err.Error()))
+		return
+	}
+	log.Debug("Enrichment job rescheduled", slog.Any("missing", remaining), slog.Duration("delay", delay))
+}
+
+func (ew *EnrichmentWorkers) delete(job *models.EnrichmentJob) {
+	if err := ew.Jobs.Delete(job.ID); err != nil {
+		ew.Log.Error("Cannot delete enrichment job", slog.Int("job", job.ID), slog.String("error", err.Error()))
+	}
+}
+
+// lockTimeout - время, после которого задача упавшего воркера снова доступна другим
+func (ew *EnrichmentWorkers) lockTimeout() time.Duration {
+	return 4 * ew.PersonService.lookupTimeout()
+}
@@ -0,0 +1,524 @@
+package services
+
+import (
+	"EfectiveMobile/internal/dto"
+	"EfectiveMobile/internal/enrichers"
+	"EfectiveMobile/internal/models"
+	"EfectiveMobile/pkg/translit"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+type PartialPolicy string
+
+type QuotaMode string
+
+var ErrUnavailable = errors.New("enrichment is temporarily unavailable")
+
+const (
+	// PartialFail - запрос на создание завершается ошибкой, если хотя бы один провайдер не ответил
+	PartialFail PartialPolicy = "fail"
+	// PartialStore - человек сохраняется с пустыми значениями для неполученных атрибутов
+	PartialStore PartialPolicy = "store"
+	// PartialRetry - человек сохраняется, а неполученные атрибуты запрашиваются повторно в фоне
+	PartialRetry PartialPolicy = "retry"
+
+	attributeAge         = "age"
+	attributeGender      = "gender"
+	attributeNationality = "nationality"
+
+	// QuotaQueue - при исчерпании квоты человек сохраняется, а атрибуты запрашиваются позже
+	QuotaQueue QuotaMode = "queue"
+	// QuotaCache - при исчерпании квоты используются только данные из кэша
+	QuotaCache QuotaMode = "cache"
+	// QuotaReject - при исчерпании квоты создание отклоняется с ErrUnavailable
+	QuotaReject QuotaMode = "reject"
+
+	statusComplete = "complete"
+	statusPartial  = "partial"
+
+	defaultLookupTimeout = 5 * time.Second
+	defaultRetryDelay    = time.Minute
+	defaultRetryAttempts = 3
+)
+
+var allAttributes = []string{attributeAge, attributeGender, attributeNationality}
+
+func ParsePartialPolicy(s string) (PartialPolicy, error) {
+	switch p := PartialPolicy(s); p {
+	case PartialFail, PartialStore, PartialRetry:
+		return p, nil
+	case "":
+		return PartialFail, nil
+	default:
+		return "", fmt.Errorf("unknown partial policy: %s", s)
+	}
+}
+
+func ParseQuotaMode(s string) (QuotaMode, error) {
+	switch m := QuotaMode(s); m {
+	case QuotaQueue, QuotaCache, QuotaReject:
+		return m, nil
+	case "":
+		return QuotaQueue, nil
+	default:
+		return "", fmt.Errorf("unknown quota mode: %s", s)
+	}
+}
+
+// enrich дополняет person указанными атрибутами: сначала из кэша, остальные параллельно
+// у провайдеров, у каждого запроса свой дедлайн. Исправленные вручную атрибуты не запрашиваются.
+// Ошибки возвращаются по имени атрибута
+func (ps *PersonService) enrich(ctx context.Context, person *models.Person, attributes []string) map[string]error {
+	attributes = slices.DeleteFunc(slices.Clone(attributes), func(attribute string) bool { return isManual(person, attribute) })
+	q := personQuery(person)
+
+	entry := enrichers.Result{}
+	if ps.Cache != nil {
+		if c, ok := ps.Cache.Get(q); ok {
+			entry = *c
+		}
+	}
+	cached := entry
+	if byPatronymic := patronymicGender(q); byPatronymic != nil {
+		cached.Gender = byPatronymic
+	}
+
+	pending := missingAttributes(cached, attributes)
+	fetched, errs := ps.fetch(ctx, nameQuery(q), pending)
+	if ps.Cache != nil && len(errs) < len(pending) {
+		ps.Cache.Put(q, entry.Merge(cacheable(fetched)))
+	}
+
+	result := ps.withSurnameGender(q, cached.Merge(fetched), attributes, errs)
+	applyResult(person, result, attributes)
+	return errs
+}
+
+// cacheMode - как enrichBatch использует кэш
+type cacheMode int
+
+const (
+	// cacheReadWrite - значения берутся из кэша, а полученные у провайдеров сохраняются в него
+	cacheReadWrite cacheMode = iota
+	// cacheRefresh - кэш не читается, а только обновляется свежими данными
+	cacheRefresh
+	// cacheBypass - кэш не читается и не обновляется, например при пробном переобогащении
+	cacheBypass
+)
+
+// enrichBatch - пакетный вариант enrich: одинаковые имена запрашиваются один раз, а остальные
+// группируются в запросы по enrichers.MaxBatchSize. Ошибки возвращаются для каждого человека.
+// Атрибут запрашивается для имени, только если он исправлен вручную не у всех людей с этим именем.
+// Пол по отчеству и фамилии определяется для каждого человека отдельно и не дробит группы
+func (ps *PersonService) enrichBatch(ctx context.Context, persons []*models.Person, attributes []string, mode cacheMode) []map[string]error {
+	type group struct {
+		q       enrichers.Query
+		cached  enrichers.Result
+		fetched enrichers.Result
+		errs    map[string]error
+		members []int
+		// needed - атрибуты, не исправленные вручную хотя бы у одного из members; пол не нужен
+		// людям, у которых он определяется по отчеству
+		needed map[string]bool
+	}
+
+	groups := []*group{}
+	byKey := map[string]*group{}
+	for i, p := range persons {
+		q := personQuery(p)
+		key := cacheKey(q)
+		g, ok := byKey[key]
+		if !ok {
+			g = &group{q: nameQuery(q), errs: map[string]error{}, needed: map[string]bool{}}
+			if ps.Cache != nil && mode == cacheReadWrite {
+				if c, ok := ps.Cache.Get(q); ok {
+					g.cached = *c
+				}
+			}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		g.members = append(g.members, i)
+		for _, attribute := range attributes {
+			if isManual(p, attribute) || (attribute == attributeGender && patronymicGender(q) != nil) {
+				continue
+			}
+			g.needed[attribute] = true
+		}
+	}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for _, attribute := range attributes {
+		pending := []*group{}
+		for _, g := range groups {
+			if g.needed[attribute] && len(missingAttributes(g.cached, []string{attribute})) > 0 {
+				pending = append(pending, g)
+			}
+		}
+		if len(pending) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			qs := make([]enrichers.Query, len(pending))
+			for i, g := range pending {
+				qs[i] = g.q
+			}
+			chunks := (len(qs) + enrichers.MaxBatchSize - 1) / enrichers.MaxBatchSize
+			lookupCtx, cancel := context.WithTimeout(ctx, time.Duration(chunks)*ps.lookupTimeout())
+			defer cancel()
+
+			results, errs := ps.lookupBatch(lookupCtx, qs, attribute)
+
+			mu.Lock()
+			defer mu.Unlock()
+			for i, g := range pending {
+				switch {
+				case errors.Is(errs[i], enrichers.ErrNotConfigured), errors.Is(errs[i], enrichers.ErrNoSignal):
+				case errs[i] != nil:
+					g.errs[attribute] = errs[i]
+				default:
+					g.fetched = g.fetched.Merge(results[i])
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	out := make([]map[string]error, len(persons))
+	for _, g := range groups {
+		byName := g.cached.Merge(g.fetched)
+		if fetched := cacheable(g.fetched); ps.Cache != nil && mode != cacheBypass && fetched != (enrichers.Result{}) {
+			entry := fetched.Merge(g.cached)
+			if c, ok := ps.Cache.Get(g.q); ok {
+				entry = entry.Merge(*c)
+			}
+			ps.Cache.Put(g.q, entry)
+		}
+		for _, i := range g.members {
+			q := personQuery(persons[i])
+			result := byName
+			if byPatronymic := patronymicGender(q); byPatronymic != nil {
+				result.Gender = byPatronymic
+			}
+			errs := maps.Clone(g.errs)
+			result = ps.withSurnameGender(q, result, attributes, errs)
+			applyResult(persons[i], result, attributes)
+			out[i] = errs
+		}
+	}
+	return out
+}
+
+// lookupBatch запрашивает атрибут для нескольких имён; если Enricher не умеет
+// пакетные запросы, имена запрашиваются по одному
+func (ps *PersonService) lookupBatch(ctx context.Context, qs []enrichers.Query, attribute string) ([]enrichers.Result, []error) {
+	results := make([]enrichers.Result, len(qs))
+
+	batch, ok := ps.Enricher.(enrichers.BatchEnricher)
+	if !ok {
+		errs := make([]error, len(qs))
+		for i, q := range qs {
+			results[i], errs[i] = ps.lookup(ctx, q, attribute)
+		}
+		return results, errs
+	}
+
+	switch attribute {
+	case attributeAge:
+		res, errs := batch.EnrichAgeBatch(ctx, qs)
+		for i := range res {
+			results[i].Age = res[i]
+		}
+		return results, errs
+	case attributeGender:
+		res, errs := batch.EnrichGenderBatch(ctx, qs)
+		for i := range res {
+			results[i].Gender = res[i]
+		}
+		return results, errs
+	case attributeNationality:
+		res, errs := batch.EnrichNationalityBatch(ctx, qs)
+		for i := range res {
+			results[i].Nationality = res[i]
+		}
+		return results, errs
+	default:
+		errs := make([]error, len(qs))
+		for i := range errs {
+			errs[i] = fmt.Errorf("unknown attribute: %s", attribute)
+		}
+		return results, errs
+	}
+}
+
+func (ps *PersonService) fetch(ctx context.Context, q enrichers.Query, attributes []string) (enrichers.Result, map[string]error) {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		result = enrichers.Result{}
+		errs   = map[string]error{}
+	)
+	for _, attribute := range attributes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			lookupCtx, cancel := context.WithTimeout(ctx, ps.lookupTimeout())
+			defer cancel()
+
+			res, err := ps.lookup(lookupCtx, q, attribute)
+			if errors.Is(err, enrichers.ErrNotConfigured) || errors.Is(err, enrichers.ErrNoSignal) {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[attribute] = err
+				return
+			}
+			result = result.Merge(res)
+		}()
+	}
+	wg.Wait()
+
+	return result, errs
+}
+
+func (ps *PersonService) lookup(ctx context.Context, q enrichers.Query, attribute string) (enrichers.Result, error) {
+	switch attribute {
+	case attributeAge:
+		res, err := ps.Enricher.EnrichAge(ctx, q)
+		return enrichers.Result{Age: res}, err
+	case attributeGender:
+		res, err := ps.Enricher.EnrichGender(ctx, q)
+		return enrichers.Result{Gender: res}, err
+	case attributeNationality:
+		res, err := ps.Enricher.EnrichNationality(ctx, q)
+		return enrichers.Result{Nationality: res}, err
+	default:
+		return enrichers.Result{}, fmt.Errorf("unknown attribute: %s", attribute)
+	}
+}
+
+// personQuery возвращает запрос к провайдерам по транслитерированному имени и стране человека.
+// Транслитерации может не быть у человека, созданного до её появления, тогда используется исходное имя.
+// Фамилия и отчество нужны только правилам определения пола, которые знают окончания в кириллице,
+// поэтому они передаются как есть
+func personQuery(person *models.Person) enrichers.Query {
+	return enrichers.Query{
+		Name:       translit.Plain(cmp.Or(person.NameTranslit, person.Name)),
+		Surname:    person.Surname,
+		Patronymic: person.Patronymic,
+		CountryID:  person.CountryHint,
+	}
+}
+
+// nameQuery - запрос только по имени и стране: ответ на него общий для всех людей с этим именем
+// и может кэшироваться, а пол по отчеству и фамилии определяется отдельно для каждого человека
+func nameQuery(q enrichers.Query) enrichers.Query {
+	q.Surname, q.Patronymic = "", ""
+	return q
+}
+
+// patronymicGender возвращает пол по отчеству; он надёжнее любого ответа по имени,
+// поэтому заменяет и закэшированный, и полученный от провайдеров пол
+func patronymicGender(q enrichers.Query) *enrichers.GenderResult {
+	res, err := enrichers.PatronymicGender{}.EnrichGender(context.Background(), q)
+	if err != nil || res.Gender == nil {
+		return nil
+	}
+	return res
+}
+
+// withSurnameGender - последнее средство: если пол по имени не определён или определён
+// с уверенностью ниже GenderMinConfidence, а фамилия его подсказывает, в result подставляется
+// пол по фамилии, а ошибка получения пола убирается из errs
+func (ps *PersonService) withSurnameGender(q enrichers.Query, result enrichers.Result, attributes []string, errs map[string]error) enrichers.Result {
+	if !slices.Contains(attributes, attributeGender) {
+		return result
+	}
+	if g := result.Gender; g != nil && g.Gender != nil && g.Probability >= ps.GenderMinConfidence {
+		return result
+	}
+	bySurname, err := enrichers.SurnameGender{}.EnrichGender(context.Background(), q)
+	if err != nil || bySurname.Gender == nil {
+		return result
+	}
+	result.Gender = bySurname
+	delete(errs, attributeGender)
+	return result
+}
+
+// cacheable убирает из результата ответы, которые нельзя кэшировать по имени: пол по отчеству и фамилии
+// зависит не только от имени, а ответы локального провайдера - временная замена HTTP-провайдерам,
+// которая не должна пережить их недоступность
+func cacheable(result enrichers.Result) enrichers.Result {
+	if result.Age != nil && result.Age.Provider == enrichers.ProviderLocal {
+		result.Age = nil
+	}
+	if result.Gender != nil && (result.Gender.Provider == enrichers.ProviderPatronymic || result.Gender.Provider == enrichers.ProviderSurname || result.Gender.Provider == enrichers.ProviderLocal) {
+		result.Gender = nil
+	}
+	if result.Nationality != nil && result.Nationality.Provider == enrichers.ProviderLocal {
+		result.Nationality = nil
+	}
+	return result
+}
+
+func missingAttributes(result enrichers.Result, attributes []string) []string {
+	missing := []string{}
+	for _, attribute := range attributes {
+		switch {
+		case attribute == attributeAge && result.Age != nil:
+		case attribute == attributeGender && result.Gender != nil:
+		case attribute == attributeNationality && result.Nationality != nil:
+		default:
+			missing = append(missing, attribute)
+		}
+	}
+	return missing
+}
+
+// applyResult переносит полученные атрибуты в person и отмечает их происхождение.
+// Исправленные вручную атрибуты остаются без изменений
+func applyResult(person *models.Person, result enrichers.Result, attributes []string) {
+	now := time.Now()
+	for _, attribute := range attributes {
+		if isManual(person, attribute) {
+			continue
+		}
+		switch {
+		case attribute == attributeAge && result.Age != nil:
+			person.Age = result.Age.Age
+			person.AgeCount = result.Age.Count
+			setProvenance(person, attribute, models.FieldProvenance{Source: models.SourceEnriched, Provider: result.Age.Provider, UpdatedAt: now})
+		case attribute == attributeGender && result.Gender != nil:
+			person.Gender = result.Gender.Gender
+			person.GenderProbability = result.Gender.Probability
+			person.GenderCount = result.Gender.Count
+			setProvenance(person, attribute, models.FieldProvenance{Source: models.SourceEnriched, Provider: result.Gender.Provider, UpdatedAt: now})
+		case attribute == attributeNationality && result.Nationality != nil:
+			person.Nationality = result.Nationality.Nationality
+			person.NationalityProbability = result.Nationality.Probability
+			person.Nationalities = []models.PersonNationality{}
+			for i, c := range result.Nationality.Countries {
+				person.Nationalities = append(person.Nationalities, models.PersonNationality{CountryID: c.CountryID, Probability: c.Probability, Rank: i + 1})
+			}
+			setProvenance(person, attribute, models.FieldProvenance{Source: models.SourceEnriched, Provider: result.Nationality.Provider, UpdatedAt: now})
+		}
+	}
+}
+
+func isManual(person *models.Person, attribute string) bool {
+	return person.Provenance[attribute].Source == models.SourceManual
+}
+
+func setProvenance(person *models.Person, attribute string, provenance models.FieldProvenance) {
+	if person.Provenance == nil {
+		person.Provenance = map[string]models.FieldProvenance{}
+	}
+	person.Provenance[attribute] = provenance
+}
+
+func makeReport(errs map[string]error) dto.EnrichmentReport {
+	report := dto.EnrichmentReport{Status: statusComplete}
+	if len(errs) == 0 {
+		return report
+	}
+
+	report.Status = statusPartial
+	report.Errors = map[string]string{}
+	for _, attribute := range allAttributes {
+		if err, ok := errs[attribute]; ok {
+			report.Missing = append(report.Missing, attribute)
+			report.Errors[attribute] = err.Error()
+			if errors.Is(err, enrichers.ErrQuotaExhausted) {
+				report.Degraded = true
+			}
+		}
+	}
+	return report
+}
+
+// splitQuotaErrors отделяет ошибки исчерпания квоты от остальных
+func splitQuotaErrors(errs map[string]error) (quota []string, other map[string]error) {
+	other = map[string]error{}
+	for _, attribute := range allAttributes {
+		err, ok := errs[attribute]
+		switch {
+		case !ok:
+		case errors.Is(err, enrichers.ErrQuotaExhausted):
+			quota = append(quota, attribute)
+		default:
+			other[attribute] = err
+		}
+	}
+	return quota, other
+}
+
+// scheduleRetry ставит в очередь задачу на повторный запрос неполученных атрибутов
+// уже сохранённого человека; задачу выполнит EnrichmentWorkers. Если все атрибуты
+// не получены из-за квоты, задача откладывается до её сброса
+func (ps *PersonService) scheduleRetry(id int, attributes []string, errs map[string]error) error {
+	runAt := time.Now().Add(ps.retryDelay())
+	if resetAt, ok := ps.quotaRetry(errs, attributes); ok {
+		runAt = resetAt
+	}
+	return ps.Jobs.Enqueue(id, attributes, runAt)
+}
+
+// quotaRetry сообщает, что все attributes не получены из-за исчерпания квоты, и возвращает
+// время, когда их стоит запросить снова: самый поздний сброс квоты или, если он неизвестен,
+// через RetryDelay
+func (ps *PersonService) quotaRetry(errs map[string]error, attributes []string) (time.Time, bool) {
+	if len(attributes) == 0 {
+		return time.Time{}, false
+	}
+	runAt := time.Now().Add(ps.retryDelay())
+	for _, attribute := range attributes {
+		err := errs[attribute]
+		if !errors.Is(err, enrichers.ErrQuotaExhausted) {
+			return time.Time{}, false
+		}
+		if resetAt, ok := enrichers.QuotaResetAt(err); ok && resetAt.After(runAt) {
+			runAt = resetAt
+		}
+	}
+	return runAt, true
+}
+
+func (ps *PersonService) lookupTimeout() time.Duration {
+	if ps.LookupTimeout > 0 {
+		return ps.LookupTimeout
+	}
+	return defaultLookupTimeout
+}
+
+func (ps *PersonService) retryDelay() time.Duration {
+	if ps.RetryDelay > 0 {
+		return ps.RetryDelay
+	}
+	return defaultRetryDelay
+}
+
+func (ps *PersonService) retryAttempts() int {
+	if ps.RetryAttempts > 0 {
+		return ps.RetryAttempts
+	}
+	return defaultRetryAttempts
+}
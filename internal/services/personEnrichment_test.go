@@ -0,0 +1,104 @@
+package services
+
+import (
+	"EfectiveMobile/internal/enrichers"
+	"EfectiveMobile/internal/models"
+	"EfectiveMobile/pkg/fakeenrich"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+// newFakeService возвращает сервис без БД и кэша, обогащающий через цепочки по умолчанию
+// с HTTP-провайдерами на фейковом сервере
+func newFakeService(t *testing.T, srv *fakeenrich.Server, genderMinConfidence float64) *PersonService {
+	t.Helper()
+	registry := enrichers.NewDefaultRegistry(http.DefaultClient, enrichers.RetryPolicy{MaxAttempts: 1}, enrichers.BreakerSettings{}, map[string]enrichers.ProviderSettings{
+		enrichers.ProviderAgify:       {Enabled: true, URL: srv.AgifyURL()},
+		enrichers.ProviderGenderize:   {Enabled: true, URL: srv.GenderizeURL()},
+		enrichers.ProviderNationalize: {Enabled: true, URL: srv.NationalizeURL()},
+	})
+	set, err := registry.BuildChains(
+		enrichers.ChainSettings{Providers: []string{enrichers.ProviderAgify}},
+		enrichers.ChainSettings{Providers: []string{enrichers.ProviderPatronymic, enrichers.ProviderGenderize, enrichers.ProviderSurname}, MinConfidence: genderMinConfidence},
+		enrichers.ChainSettings{Providers: []string{enrichers.ProviderNationalize}},
+	)
+	if err != nil {
+		t.Fatalf("BuildChains: %v", err)
+	}
+	return &PersonService{Enricher: set, Log: slog.New(slog.NewTextHandler(io.Discard, nil)), GenderMinConfidence: genderMinConfidence}
+}
+
+func TestEnrichBatchSharesNameRequests(t *testing.T) {
+	srv := fakeenrich.NewServer(fakeenrich.Options{})
+	defer srv.Close()
+	ps := newFakeService(t, srv, 0.5)
+
+	persons := []*models.Person{}
+	for _, surname := range []string{"Иванов", "Петров", "Сидоров", "Кузнецов"} {
+		persons = append(persons, &models.Person{Name: "Иван", NameTranslit: "Ivan", Surname: surname, Patronymic: "Петрович"})
+	}
+	recorder := &enrichers.Recorder{}
+	errs := ps.enrichBatch(enrichers.WithRecorder(context.Background(), recorder), persons, allAttributes, cacheReadWrite)
+
+	for i, person := range persons {
+		if len(errs[i]) > 0 {
+			t.Errorf("person %d errors = %v", i, errs[i])
+		}
+		if person.Age == nil || person.Nationality == nil {
+			t.Errorf("person %d age = %v, nationality = %v; want both set", i, person.Age, person.Nationality)
+		}
+		if person.Gender == nil || *person.Gender != "male" || person.Provenance[attributeGender].Provider != enrichers.ProviderPatronymic {
+			t.Errorf("person %d gender = %v by %q; want male by patronymic", i, person.Gender, person.Provenance[attributeGender].Provider)
+		}
+	}
+	for _, e := range recorder.For("Ivan") {
+		if n := len(slices.DeleteFunc(slices.Clone(e.Names), func(name string) bool { return name != "Ivan" })); n != 1 {
+			t.Errorf("%s request has Ivan %d times; want once", e.Provider, n)
+		}
+	}
+	for path, want := range map[string]int{fakeenrich.AgifyPath: 1, fakeenrich.GenderizePath: 0, fakeenrich.NationalizePath: 1} {
+		if got := srv.Handler.Requests(path); got != want {
+			t.Errorf("requests to %s = %d; want %d", path, got, want)
+		}
+	}
+}
+
+func TestSurnameGenderIsLastResort(t *testing.T) {
+	srv := fakeenrich.NewServer(fakeenrich.Options{NullNames: []string{"Nobody"}})
+	defer srv.Close()
+
+	tests := []struct {
+		name          string
+		firstName     string
+		minConfidence float64
+		gender        string
+		provider      string
+	}{
+		{"confident name gender wins", "Sasha", 0.5, "female", enrichers.ProviderGenderize},
+		{"surname below threshold", "Sasha", 1, "male", enrichers.ProviderSurname},
+		{"surname for unknown name", "Nobody", 0.5, "male", enrichers.ProviderSurname},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := newFakeService(t, srv, tt.minConfidence)
+			single := &models.Person{Name: tt.firstName, NameTranslit: tt.firstName, Surname: "Петров"}
+			batch := &models.Person{Name: tt.firstName, NameTranslit: tt.firstName, Surname: "Петров"}
+
+			if errs := ps.enrich(context.Background(), single, []string{attributeGender}); len(errs) > 0 {
+				t.Fatalf("enrich errors = %v", errs)
+			}
+			if errs := ps.enrichBatch(context.Background(), []*models.Person{batch}, []string{attributeGender}, cacheReadWrite); len(errs[0]) > 0 {
+				t.Fatalf("enrichBatch errors = %v", errs[0])
+			}
+			for _, p := range []*models.Person{single, batch} {
+				if p.Gender == nil || *p.Gender != tt.gender || p.Provenance[attributeGender].Provider != tt.provider {
+					t.Errorf("gender = %v by %q; want %s by %s", p.Gender, p.Provenance[attributeGender].Provider, tt.gender, tt.provider)
+				}
+			}
+		})
+	}
+}
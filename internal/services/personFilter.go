@@ -0,0 +1,299 @@
+package services
+
+import (
+	"EfectiveMobile/internal/dto"
+	"EfectiveMobile/internal/filter"
+	"EfectiveMobile/pkg/translit"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	operatorIs       = "is"
+	operatorIsnt     = "isnt"
+	operatorLs       = "ls"
+	operatorMt       = "mt"
+	operatorLte      = "lte"
+	operatorGte      = "gte"
+	operatorIn       = "in"
+	operatorNin      = "nin"
+	operatorBetween  = "between"
+	operatorPrefix   = "prefix"
+	operatorContains = "contains"
+	operatorIlike    = "ilike"
+	operatorAny      = "any"
+
+	operatorIsNull  = "isnull"
+	operatorNotNull = "notnull"
+)
+
+// maxFilterValues ограничивает длину списка в in и nin
+const maxFilterValues = 100
+
+// operators сопоставляет операторы параметров запроса с операторами фильтра
+var operators = map[string]filter.Operator{
+	operatorIs:       filter.Eq,
+	operatorIsnt:     filter.Ne,
+	operatorLs:       filter.Lt,
+	operatorMt:       filter.Gt,
+	operatorLte:      filter.Lte,
+	operatorGte:      filter.Gte,
+	operatorIn:       filter.In,
+	operatorNin:      filter.NotIn,
+	operatorBetween:  filter.Between,
+	operatorPrefix:   filter.Prefix,
+	operatorContains: filter.Contains,
+	operatorIlike:    filter.EqualFold,
+	operatorAny:      filter.Any,
+	operatorIsNull:   filter.IsNull,
+	operatorNotNull:  filter.NotNull,
+}
+
+var (
+	textOperators        = []string{operatorIs, operatorIsnt, operatorIn, operatorNin, operatorPrefix, operatorContains, operatorIlike}
+	nullableOperators    = append(slices.Clone(textOperators), operatorIsNull, operatorNotNull)
+	nationalityOperators = append(slices.Clone(nullableOperators), operatorAny)
+	measureOperators     = []string{operatorIs, operatorIsnt, operatorLs, operatorMt, operatorLte, operatorGte, operatorBetween}
+	ageOperators         = append(slices.Clone(measureOperators), operatorIn, operatorNin, operatorIsNull, operatorNotNull)
+)
+
+// filterParam - параметр запроса вида "оператор:значение" и поле, которое он фильтрует.
+// Параметр может повторяться, values - все его значения
+type filterParam struct {
+	name      string
+	values    []string
+	field     filter.Field
+	operators []string
+	parse     func(string) (any, error)
+}
+
+// buildFilter переводит параметры запроса в дерево условий. Значения проверяются
+// и приводятся к типу поля здесь, в SQL они попадают только как аргументы.
+// Повторённые параметры объединяются через AND, альтернативы через "|" в одном
+// значении - через OR. Группа or объединяет через OR условия на разные поля:
+// "or=gender=is:female|age=lt:30"
+func (ps *PersonService) buildFilter(filters dto.Filters) (filter.Query, error) {
+	params := ps.filterParams(filters)
+
+	where := filter.And{}
+	for _, p := range params {
+		for _, value := range p.values {
+			if value == "" {
+				continue
+			}
+			alternatives := filter.Or{}
+			for _, alternative := range splitEscaped(value, '|') {
+				condition, err := p.condition(alternative)
+				if err != nil {
+					return filter.Query{}, err
+				}
+				alternatives = append(alternatives, condition)
+			}
+			where = append(where, simplify(alternatives))
+			ps.Log.Debug("added filter parametr", slog.String(p.name, value))
+		}
+	}
+
+	for _, group := range filters.Or {
+		if group == "" {
+			continue
+		}
+		alternatives := filter.Or{}
+		for _, term := range splitEscaped(group, '|') {
+			name, value, _ := strings.Cut(term, "=")
+			i := slices.IndexFunc(params, func(p filterParam) bool { return p.name == name })
+			if i < 0 {
+				return filter.Query{}, &filter.Error{Param: "or", Message: fmt.Sprintf("unknown field %q in %q", name, term)}
+			}
+			condition, err := params[i].condition(value)
+			if err != nil {
+				return filter.Query{}, err
+			}
+			alternatives = append(alternatives, condition)
+		}
+		where = append(where, simplify(alternatives))
+		ps.Log.Debug("added filter parametr", slog.String("or", group))
+	}
+
+	sorts := []filter.Sort{}
+	for _, value := range filters.Sort {
+		for _, part := range strings.Split(value, ",") {
+			field, order, _ := strings.Cut(part, ":")
+			sort, err := parseSort(field, order)
+			if err != nil {
+				return filter.Query{}, &filter.Error{Param: "sort", Message: err.Error()}
+			}
+			sorts = append(sorts, sort)
+		}
+	}
+
+	if filters.ByLimit < 0 {
+		return filter.Query{}, &filter.Error{Param: "limit", Message: "must not be negative"}
+	}
+	if filters.ByOffset < 0 {
+		return filter.Query{}, &filter.Error{Param: "offset", Message: "must not be negative"}
+	}
+	return filter.Query{Where: where, Sort: sorts, Limit: filters.ByLimit, Offset: filters.ByOffset}, nil
+}
+
+// filterParams - поля, доступные для фильтрации, со значениями из filters
+func (ps *PersonService) filterParams(filters dto.Filters) []filterParam {
+	return []filterParam{
+		{"name", filters.ByName, filter.Name, textOperators, parseText},
+		{"surname", filters.BySurname, filter.Surname, textOperators, parseText},
+		{"patronymic", filters.ByPatronymic, filter.Patronymic, nullableOperators, parseText},
+		{"name_translit", filters.ByNameTranslit, filter.NameTranslit, textOperators, ps.parseTranslit},
+		{"surname_translit", filters.BySurnameTranslit, filter.SurnameTranslit, textOperators, ps.parseTranslit},
+		{"patronymic_translit", filters.ByPatronymicTranslit, filter.PatronymicTranslit, textOperators, ps.parseTranslit},
+		{"age", filters.ByAge, filter.Age, ageOperators, parseInt},
+		{"age_count", filters.ByAgeCount, filter.AgeCount, measureOperators, parseFloat},
+		{"gender", filters.ByGender, filter.Gender, nullableOperators, parseText},
+		{"gender_probability", filters.ByGenderProbability, filter.GenderProbability, measureOperators, parseFloat},
+		{"gender_count", filters.ByGenderCount, filter.GenderCount, measureOperators, parseFloat},
+		{"nationality", filters.ByNationality, filter.Nationality, nationalityOperators, parseText},
+		{"nationality_probability", filters.ByNationalityProbability, filter.NationalityProbability, measureOperators, parseFloat},
+	}
+}
+
+// simplify заменяет OR из одного условия самим условием
+func simplify(alternatives filter.Or) filter.Expr {
+	if len(alternatives) == 1 {
+		return alternatives[0]
+	}
+	return alternatives
+}
+
+// condition разбирает значение параметра. Оператор отделяется по первому двоеточию,
+// поэтому в самом значении двоеточия допустимы. Значения in и nin перечисляются
+// через запятую, between принимает две границы через запятую. Запятая и "|" внутри
+// значения экранируются обратной косой чертой: "is:A\|B", "in:A\,B,C"
+func (p filterParam) condition(value string) (filter.Condition, error) {
+	operator, operand, _ := strings.Cut(value, ":")
+	var operands []string
+	switch {
+	case operand == "":
+	case operator == operatorIn || operator == operatorNin || operator == operatorBetween:
+		operands = splitEscaped(operand, ',')
+	default:
+		operands = []string{operand}
+	}
+	for i := range operands {
+		operands[i] = unescape(operands[i])
+	}
+	return p.build(operator, operands)
+}
+
+// splitEscaped делит s по sep, пропуская экранированные обратной косой чертой разделители.
+// Экранирование сохраняется, его снимает unescape
+func splitEscaped(s string, sep byte) []string {
+	parts := []string{}
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unescape снимает экранирование с "\|", "\," и "\\"; остальные обратные косые черты
+// остаются в значении как есть
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`|,\`, s[i+1]) >= 0 {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// build проверяет оператор и количество значений и приводит значения к типу поля
+func (p filterParam) build(operator string, operands []string) (filter.Condition, error) {
+	if !slices.Contains(p.operators, operator) {
+		return filter.Condition{}, p.error(fmt.Sprintf("unknown operator %q, expected one of %s", operator, strings.Join(p.operators, ", ")))
+	}
+	condition := filter.Condition{Field: p.field, Operator: operators[operator]}
+	switch operator {
+	case operatorIsNull, operatorNotNull:
+		if len(operands) != 0 {
+			return filter.Condition{}, p.error(fmt.Sprintf("operator %q takes no value", operator))
+		}
+		return condition, nil
+	case operatorIn, operatorNin, operatorBetween:
+		if len(operands) == 0 {
+			return filter.Condition{}, p.error("missing value")
+		}
+		if operator == operatorBetween && len(operands) != 2 {
+			return filter.Condition{}, p.error("between needs two values")
+		}
+		if len(operands) > maxFilterValues {
+			return filter.Condition{}, p.error(fmt.Sprintf("too many values, at most %d are allowed", maxFilterValues))
+		}
+		values := make([]any, len(operands))
+		for i, operand := range operands {
+			if operand == "" {
+				return filter.Condition{}, p.error("empty value in list")
+			}
+			value, err := p.parse(operand)
+			if err != nil {
+				return filter.Condition{}, p.error(err.Error())
+			}
+			values[i] = value
+		}
+		condition.Value = values
+	default:
+		if len(operands) == 0 || operands[0] == "" {
+			return filter.Condition{}, p.error("missing value")
+		}
+		if len(operands) > 1 {
+			return filter.Condition{}, p.error(fmt.Sprintf("operator %q needs a single value", operator))
+		}
+		value, err := p.parse(operands[0])
+		if err != nil {
+			return filter.Condition{}, p.error(err.Error())
+		}
+		condition.Value = value
+	}
+	return condition, nil
+}
+
+func (p filterParam) error(message string) error {
+	return &filter.Error{Param: p.name, Message: message}
+}
+
+func parseText(value string) (any, error) {
+	return value, nil
+}
+
+func parseInt(value string) (any, error) {
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not an integer", value)
+	}
+	return number, nil
+}
+
+func parseFloat(value string) (any, error) {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a number", value)
+	}
+	return number, nil
+}
+
+// parseTranslit транслитерирует кириллическое значение тем же стандартом, что и имена людей
+func (ps *PersonService) parseTranslit(value string) (any, error) {
+	return translit.Transliterate(value, ps.Translit), nil
+}
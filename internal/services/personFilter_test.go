@@ -0,0 +1,189 @@
+package services
+
+import (
+	"EfectiveMobile/internal/dto"
+	"EfectiveMobile/internal/filter"
+	"EfectiveMobile/pkg/translit"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// escaper экранирует разделители в значении параметра
+var escaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, ",", `\,`)
+
+func fuzzFilters(text string) dto.Filters {
+	return dto.Filters{
+		ByName:         []string{"is:" + text},
+		BySurname:      []string{"prefix:" + text, "nin:" + text + "," + text},
+		ByNameTranslit: []string{"ilike:" + text},
+		ByNationality:  []string{"any:" + text + "|isnull"},
+		ByGenderCount:  []string{"gte:7"},
+		Or:             []string{"patronymic=contains:" + text + "|gender=notnull"},
+		Sort:           []string{"age:desc,name"},
+		ByLimit:        10,
+		ByOffset:       5,
+	}
+}
+
+// shape возвращает структуру дерева условий без значений - то, от чего зависит текст SQL
+func shape(expr filter.Expr) string {
+	switch e := expr.(type) {
+	case filter.And:
+		return "and(" + shapes(e) + ")"
+	case filter.Or:
+		return "or(" + shapes(e) + ")"
+	case filter.Not:
+		return "not(" + shape(e.Expr) + ")"
+	case filter.Condition:
+		if values, ok := e.Value.([]any); ok {
+			return fmt.Sprintf("%s %s [%d]", e.Field, e.Operator, len(values))
+		}
+		return fmt.Sprintf("%s %s %v", e.Field, e.Operator, e.Value != nil)
+	default:
+		return fmt.Sprintf("%T", expr)
+	}
+}
+
+func shapes(exprs []filter.Expr) string {
+	parts := make([]string, len(exprs))
+	for i, e := range exprs {
+		parts[i] = shape(e)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// values собирает значения условий в порядке обхода дерева
+func values(expr filter.Expr) []any {
+	switch e := expr.(type) {
+	case filter.And:
+		return valuesOf(e)
+	case filter.Or:
+		return valuesOf(e)
+	case filter.Not:
+		return values(e.Expr)
+	case filter.Condition:
+		if list, ok := e.Value.([]any); ok {
+			return list
+		}
+		if e.Value != nil {
+			return []any{e.Value}
+		}
+	}
+	return nil
+}
+
+func valuesOf(exprs []filter.Expr) []any {
+	result := []any{}
+	for _, e := range exprs {
+		result = append(result, values(e)...)
+	}
+	return result
+}
+
+func TestBuildFilterEscapes(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters dto.Filters
+		want    filter.Expr
+	}{
+		{
+			"escaped pipe is a literal",
+			dto.Filters{ByName: []string{`is:A\|B`}},
+			filter.Condition{Field: filter.Name, Operator: filter.Eq, Value: "A|B"},
+		},
+		{
+			"unescaped pipe is an alternative",
+			dto.Filters{ByName: []string{"is:A|is:B"}},
+			filter.Or{filter.Condition{Field: filter.Name, Operator: filter.Eq, Value: "A"}, filter.Condition{Field: filter.Name, Operator: filter.Eq, Value: "B"}},
+		},
+		{
+			"escaped comma in list",
+			dto.Filters{BySurname: []string{`in:A\,B,C`}},
+			filter.Condition{Field: filter.Surname, Operator: filter.In, Value: []any{"A,B", "C"}},
+		},
+		{
+			"escaped backslash before separator",
+			dto.Filters{BySurname: []string{`in:A\\,B`}},
+			filter.Condition{Field: filter.Surname, Operator: filter.In, Value: []any{`A\`, "B"}},
+		},
+		{
+			"other backslashes are kept",
+			dto.Filters{ByName: []string{`contains:a\b`}},
+			filter.Condition{Field: filter.Name, Operator: filter.Contains, Value: `a\b`},
+		},
+		{
+			"escaped pipe in or group",
+			dto.Filters{Or: []string{`name=is:A\|B|gender=isnull`}},
+			filter.Or{filter.Condition{Field: filter.Name, Operator: filter.Eq, Value: "A|B"}, filter.Condition{Field: filter.Gender, Operator: filter.IsNull}},
+		},
+	}
+	ps := &PersonService{Log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := ps.buildFilter(tt.filters)
+			if err != nil {
+				t.Fatalf("buildFilter: %v", err)
+			}
+			if want := (filter.And{tt.want}); !reflect.DeepEqual(query.Where, want) {
+				t.Errorf("buildFilter = %#v; want %#v", query.Where, want)
+			}
+		})
+	}
+}
+
+// FuzzBuildFilter проверяет, что значения из запроса меняют только значения условий,
+// но не поля, операторы и структуру дерева, а ошибки всегда приходят как *filter.Error
+func FuzzBuildFilter(f *testing.F) {
+	f.Add("Dmitriy")
+	f.Add("'; DROP TABLE persons; --")
+	f.Add("100%_")
+	f.Add("a:b")
+	f.Add("a,b|c")
+	f.Add("")
+	f.Add(`a\|b\`)
+	ps := &PersonService{Log: slog.New(slog.NewTextHandler(io.Discard, nil)), Translit: translit.ICAO}
+	benign, err := ps.buildFilter(fuzzFilters("x"))
+	if err != nil {
+		f.Fatalf("buildFilter: %v", err)
+	}
+
+	f.Fuzz(func(t *testing.T, text string) {
+		if _, err := ps.buildFilter(fuzzFilters(text)); err != nil {
+			var filterErr *filter.Error
+			if !errors.As(err, &filterErr) {
+				t.Fatalf("buildFilter error = %v (%T); want *filter.Error", err, err)
+			}
+		}
+		// Пустое значение отбрасывается, поэтому для него структура законно другая
+		if text == "" {
+			return
+		}
+
+		query, err := ps.buildFilter(fuzzFilters(escaper.Replace(text)))
+		if err != nil {
+			t.Fatalf("buildFilter with escaped %q: %v", text, err)
+		}
+		if got, want := shape(query.Where), shape(benign.Where); got != want {
+			t.Errorf("filter shape depends on values:\n got: %s\nwant: %s", got, want)
+		}
+		if fmt.Sprint(query.Sort, query.Limit, query.Offset) != fmt.Sprint(benign.Sort, benign.Limit, benign.Offset) {
+			t.Errorf("sort and pagination = %v %d %d; want %v %d %d", query.Sort, query.Limit, query.Offset, benign.Sort, benign.Limit, benign.Offset)
+		}
+		translitText := translit.Transliterate(text, ps.Translit)
+		want := []any{text, text, text, text, translitText, 7.0, text, text}
+		got := values(query.Where)
+		if len(got) != len(want) {
+			t.Fatalf("values = %#v; want %#v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("value %d = %#v; want %#v", i, got[i], want[i])
+			}
+		}
+	})
+}
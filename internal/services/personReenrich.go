@@ -0,0 +1,96 @@
+package services
+
+import (
+	"EfectiveMobile/internal/dto"
+	"EfectiveMobile/internal/enrichers"
+	"EfectiveMobile/internal/models"
+	"context"
+	"log/slog"
+	"reflect"
+	"slices"
+)
+
+// reenrichChunkSize - сколько человек обогащается за один вызов enrichBatch
+const reenrichChunkSize = 100
+
+// Reenrich повторно обогащает людей, выбранных по тем же фильтрам, что и GetPersonsByParams,
+// минуя кэш. Исправленные вручную поля не перезаписываются. В отчёт попадают только люди
+// с изменениями или ошибками. При dryRun ничего не записывается: ни люди, ни кэш, ни журнал обмена
+func (ps *PersonService) Reenrich(filters dto.Filters, dryRun bool) (*dto.ReenrichReport, error) {
+	selected, err := ps.GetPersonsByParams(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &dto.ReenrichReport{DryRun: dryRun, Persons: []dto.ReenrichPerson{}}
+	for chunk := range slices.Chunk(selected, reenrichChunkSize) {
+		persons := make([]*models.Person, len(chunk))
+		for i := range chunk {
+			p := chunk[i]
+			persons[i] = &p
+		}
+
+		var (
+			ctx      = context.Background()
+			recorder *enrichers.Recorder
+			mode     = cacheBypass
+		)
+		if !dryRun {
+			mode = cacheRefresh
+			ctx, recorder = ps.recording(ctx)
+		}
+		errs := ps.enrichBatch(ctx, persons, allAttributes, mode)
+		for i, person := range persons {
+			if !dryRun {
+				ps.archive(person, recorder)
+			}
+			report.Processed++
+			result := dto.ReenrichPerson{ID: person.ID, Changes: diffEnrichment(&chunk[i], person)}
+			if len(errs[i]) > 0 {
+				report.Failed++
+				result.Errors = makeReport(errs[i]).Errors
+			}
+			if len(result.Changes) > 0 || len(result.Errors) > 0 {
+				report.Persons = append(report.Persons, result)
+			}
+			if len(result.Changes) > 0 {
+				report.Changed++
+			}
+
+			// Успешное обогащение завершает и отложенные или упавшие ранее задачи
+			settled := len(errs[i]) == 0 && person.EnrichmentStatus != models.EnrichmentDone
+			if dryRun || (len(result.Changes) == 0 && !settled) {
+				continue
+			}
+			if len(errs[i]) == 0 {
+				person.EnrichmentStatus = models.EnrichmentDone
+			}
+			if err := ps.PersonRepo.SaveEnrichment(person); err != nil {
+				ps.Log.Error("Cannot update reenriched person", slog.Int("id", person.ID), slog.String("error", err.Error()))
+				return nil, err
+			}
+		}
+	}
+
+	ps.Log.Info("Reenrichment finished", slog.Bool("dry run", dryRun), slog.Int("processed", report.Processed), slog.Int("changed", report.Changed), slog.Int("failed", report.Failed))
+	return report, nil
+}
+
+// diffEnrichment возвращает изменившиеся обогащаемые поля по имени в JSON
+func diffEnrichment(old, updated *models.Person) map[string]dto.FieldChange {
+	changes := map[string]dto.FieldChange{}
+	add := func(field string, o, n any) {
+		if !reflect.DeepEqual(o, n) {
+			changes[field] = dto.FieldChange{Old: o, New: n}
+		}
+	}
+	add("age", old.Age, updated.Age)
+	add("age_count", old.AgeCount, updated.AgeCount)
+	add("gender", old.Gender, updated.Gender)
+	add("gender_probability", old.GenderProbability, updated.GenderProbability)
+	add("gender_count", old.GenderCount, updated.GenderCount)
+	add("nationality", old.Nationality, updated.Nationality)
+	add("nationality_probability", old.NationalityProbability, updated.NationalityProbability)
+	add("nationalities", old.Nationalities, updated.Nationalities)
+	return changes
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"EfectiveMobile/internal/dto"
+	"EfectiveMobile/internal/filter"
+	"EfectiveMobile/internal/models"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+const (
+	// maxSearchDepth и maxSearchConditions ограничивают размер дерева условий поиска
+	maxSearchDepth      = 10
+	maxSearchConditions = 100
+)
+
+// sortFields - поля, по которым можно сортировать
+var sortFields = []filter.Field{
+	filter.ID, filter.Name, filter.Surname, filter.Patronymic, filter.Age, filter.AgeCount, filter.Gender,
+	filter.GenderProbability, filter.GenderCount, filter.Nationality, filter.NationalityProbability,
+}
+
+// SearchPersons ищет людей по дереву условий. Условия проверяются и компилируются
+// так же, как параметры GetPersonsByParams
+func (ps *PersonService) SearchPersons(search *dto.SearchPersons) ([]models.Person, error) {
+	query := filter.Query{Where: filter.And{}, Limit: search.Limit, Offset: search.Offset}
+	if search.Filter != nil {
+		conditions := 0
+		where, err := ps.searchExpr(search.Filter, "filter", 0, &conditions)
+		if err != nil {
+			return nil, err
+		}
+		query.Where = where
+	}
+	for i, s := range search.Sort {
+		sort, err := parseSort(s.Field, s.Order)
+		if err != nil {
+			return nil, &filter.Error{Param: fmt.Sprintf("sort[%d]", i), Message: err.Error()}
+		}
+		query.Sort = append(query.Sort, sort)
+	}
+	return ps.PersonRepo.GetPersonsByParams(query)
+}
+
+// searchExpr переводит узел запроса в дерево условий; path - путь к узлу для сообщений об ошибках
+func (ps *PersonService) searchExpr(node *dto.SearchFilter, path string, depth int, conditions *int) (filter.Expr, error) {
+	if depth > maxSearchDepth {
+		return nil, &filter.Error{Param: path, Message: fmt.Sprintf("nesting is deeper than %d", maxSearchDepth)}
+	}
+
+	kinds := 0
+	for _, set := range []bool{node.And != nil, node.Or != nil, node.Not != nil, node.Field != "" || node.Operator != "" || node.Value != nil} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return nil, &filter.Error{Param: path, Message: "exactly one of and, or, not or field must be set"}
+	}
+
+	switch {
+	case node.And != nil:
+		and := filter.And{}
+		for i := range node.And {
+			expr, err := ps.searchExpr(&node.And[i], fmt.Sprintf("%s.and[%d]", path, i), depth+1, conditions)
+			if err != nil {
+				return nil, err
+			}
+			and = append(and, expr)
+		}
+		return and, nil
+	case node.Or != nil:
+		or := filter.Or{}
+		for i := range node.Or {
+			expr, err := ps.searchExpr(&node.Or[i], fmt.Sprintf("%s.or[%d]", path, i), depth+1, conditions)
+			if err != nil {
+				return nil, err
+			}
+			or = append(or, expr)
+		}
+		return or, nil
+	case node.Not != nil:
+		expr, err := ps.searchExpr(node.Not, path+".not", depth+1, conditions)
+		if err != nil {
+			return nil, err
+		}
+		return filter.Not{Expr: expr}, nil
+	}
+
+	*conditions++
+	if *conditions > maxSearchConditions {
+		return nil, &filter.Error{Param: path, Message: fmt.Sprintf("more than %d conditions", maxSearchConditions)}
+	}
+	params := ps.filterParams(dto.Filters{})
+	i := slices.IndexFunc(params, func(p filterParam) bool { return p.name == node.Field })
+	if i < 0 {
+		return nil, &filter.Error{Param: path, Message: fmt.Sprintf("unknown field %q", node.Field)}
+	}
+	operands, err := searchOperands(node.Value)
+	if err != nil {
+		return nil, &filter.Error{Param: path, Message: err.Error()}
+	}
+	condition, err := params[i].build(node.Operator, operands)
+	var filterErr *filter.Error
+	if errors.As(err, &filterErr) {
+		return nil, &filter.Error{Param: path, Message: fmt.Sprintf("%s: %s", node.Field, filterErr.Message)}
+	}
+	return condition, err
+}
+
+// searchOperands приводит значение из JSON к строкам, которые разбираются так же,
+// как значения параметров запроса
+func searchOperands(value any) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		operands := make([]string, len(v))
+		for i, item := range v {
+			operand, err := searchOperand(item)
+			if err != nil {
+				return nil, err
+			}
+			operands[i] = operand
+		}
+		return operands, nil
+	default:
+		operand, err := searchOperand(v)
+		if err != nil {
+			return nil, err
+		}
+		return []string{operand}, nil
+	}
+}
+
+func searchOperand(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("value must be a string, a number or an array of them")
+	}
+}
+
+// parseSort проверяет поле и направление сортировки
+func parseSort(field, order string) (filter.Sort, error) {
+	if !slices.Contains(sortFields, filter.Field(field)) {
+		return filter.Sort{}, fmt.Errorf("unknown sort field %q", field)
+	}
+	switch order {
+	case "", "asc":
+		return filter.Sort{Field: filter.Field(field)}, nil
+	case "desc":
+		return filter.Sort{Field: filter.Field(field), Desc: true}, nil
+	default:
+		return filter.Sort{}, fmt.Errorf("unknown sort order %q, expected asc or desc", order)
+	}
+}
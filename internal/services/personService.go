@@ -2,32 +2,42 @@ package services
 
 import (
 	"EfectiveMobile/internal/dto"
+	"EfectiveMobile/internal/enrichers"
 	"EfectiveMobile/internal/models"
 	"EfectiveMobile/internal/repositories"
+	"EfectiveMobile/pkg/translit"
+	"cmp"
 	"context"
-	"encoding/json"
 	"fmt"
 	"log/slog"
-	"net/http"
+	"slices"
 	"strings"
 	"time"
 	"unicode"
 )
 
-const (
-	operatorIs   = "is"
-	operatorIsnt = "isnt"
-	operatorLs   = "ls"
-	operatorMt   = "mt"
-
-	apiGetAge         = "https://api.agify.io/?name=%s"
-	apiGetGender      = "https://api.genderize.io/?name=%s"
-	apiGetNationality = "https://api.nationalize.io/?name=%s"
-)
-
 type PersonService struct {
 	PersonRepo *repositories.PersonRepo
-	Log        *slog.Logger
+	Enricher   enrichers.Enricher
+	Cache      *EnrichmentCache
+	Jobs       *repositories.EnrichmentJobRepo
+	// EnrichmentLog - журнал запросов к провайдерам; nil выключает журнал
+	EnrichmentLog *repositories.EnrichmentLogRepo
+	Log           *slog.Logger
+
+	// Async - создавать человека сразу со статусом pending и обогащать в фоне
+	Async         bool
+	LookupTimeout time.Duration
+	PartialPolicy PartialPolicy
+	QuotaMode     QuotaMode
+	RetryDelay    time.Duration
+	RetryAttempts int
+	// Translit - стандарт транслитерации имён для запросов к провайдерам
+	Translit translit.Standard
+	// CountryHint - страна по умолчанию для людей, созданных без country_hint
+	CountryHint string
+	// GenderMinConfidence - уверенность пола по имени, ниже которой вместо него берётся пол по фамилии
+	GenderMinConfidence float64
 }
 
 func (ps *PersonService) GetPersonsByID(id int) (*models.Person, error) {
@@ -36,121 +46,180 @@ func (ps *PersonService) GetPersonsByID(id int) (*models.Person, error) {
 }
 
 func (ps *PersonService) GetPersonsByParams(filters dto.Filters) ([]models.Person, error) {
-	params := []string{}
-	if filters.ByName != "" {
-		validate := strings.Split(filters.ByName, ":")
-		switch validate[0] {
-		case operatorIs:
-			params = append(params, fmt.Sprintf("AND name = '%s'", validate[1]))
-			ps.Log.Debug("added filter parametr 'name is'", slog.String("name", validate[1]))
-		case operatorIsnt:
-			params = append(params, fmt.Sprintf("AND name != '%s'", validate[1]))
-			ps.Log.Debug("added filter parametr 'name is not'", slog.String("name", validate[1]))
-		default:
-			return nil, fmt.Errorf("invalid name param")
+	query, err := ps.buildFilter(filters)
+	if err != nil {
+		return nil, err
+	}
+	return ps.PersonRepo.GetPersonsByParams(query)
+}
+
+// validateName допускает буквы любого алфавита, а также пробел, дефис и апостроф
+// в составных именах
+func validateName(name string) error {
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !strings.ContainsRune(" -'", r) {
+			return fmt.Errorf("name must contain only letters")
 		}
 	}
-	if filters.BySurname != "" {
-		validate := strings.Split(filters.BySurname, ":")
-		switch validate[0] {
-		case operatorIs:
-			params = append(params, fmt.Sprintf("AND surname = '%s'", validate[1]))
-			ps.Log.Debug("added filter parametr 'surname is'", slog.String("surname", validate[1]))
-		case operatorIsnt:
-			params = append(params, fmt.Sprintf("AND surname != '%s'", validate[1]))
-			ps.Log.Debug("added filter parametr 'surname is not'", slog.String("surname", validate[1]))
-		default:
-			return nil, fmt.Errorf("invalid surname param")
+	return nil
+}
+
+// newPerson создаёт человека из запроса, заполняя транслитерацию и страну
+func (ps *PersonService) newPerson(personDTO *dto.CreatePerson) *models.Person {
+	person := &models.Person{
+		Name:        personDTO.Name,
+		Surname:     personDTO.Surname,
+		Patronymic:  personDTO.Patronymic,
+		CountryHint: strings.ToUpper(cmp.Or(personDTO.CountryHint, ps.CountryHint)),
+	}
+	ps.transliterate(person)
+	return person
+}
+
+// transliterate заполняет латинские формы имени, по которым идёт обогащение и поиск
+func (ps *PersonService) transliterate(person *models.Person) {
+	person.NameTranslit = translit.Transliterate(person.Name, ps.Translit)
+	person.SurnameTranslit = translit.Transliterate(person.Surname, ps.Translit)
+	person.PatronymicTranslit = translit.Transliterate(person.Patronymic, ps.Translit)
+}
+
+func (ps *PersonService) CreatePerson(personDTO *dto.CreatePerson) (*dto.CreatePersonResponse, error) {
+	if err := validateName(personDTO.Name); err != nil {
+		return nil, err
+	}
+
+	person := ps.newPerson(personDTO)
+	if ps.Async {
+		return ps.storePending(person)
+	}
+
+	ctx, recorder := ps.recording(context.Background())
+	errs := ps.enrich(ctx, person, allAttributes)
+	ps.Log.Debug("get person data from api", slog.Any("person data", person))
+
+	resp, err := ps.storeEnriched(person, errs)
+	if err != nil {
+		return nil, err
+	}
+	ps.archive(person, recorder)
+	return resp, nil
+}
+
+// CreatePersons создаёт нескольких человек, обогащая их пакетными запросами.
+// Ответ для каждого человека возвращается на той же позиции, что и в запросе
+func (ps *PersonService) CreatePersons(personDTOs []dto.CreatePerson) []dto.CreatePersonResponse {
+	responses := make([]dto.CreatePersonResponse, len(personDTOs))
+	persons := []*models.Person{}
+	positions := []int{}
+	for i, personDTO := range personDTOs {
+		if err := validateName(personDTO.Name); err != nil {
+			responses[i].Error = err.Error()
+			continue
 		}
+		persons = append(persons, ps.newPerson(&personDTO))
+		positions = append(positions, i)
 	}
-	if filters.ByPatronymic != "" {
-		validate := strings.Split(filters.ByPatronymic, ":")
-		switch validate[0] {
-		case operatorIs:
-			params = append(params, fmt.Sprintf("AND patronymic = '%s'", validate[1]))
-			ps.Log.Debug("added filter parametr 'patronymic is'", slog.String("patronymic", validate[1]))
-		case operatorIsnt:
-			params = append(params, fmt.Sprintf("AND patronymic != '%s'", validate[1]))
-			ps.Log.Debug("added filter parametr 'patronymic is not'", slog.String("patronymic", validate[1]))
-		default:
-			return nil, fmt.Errorf("invalid patronymic param")
+
+	if ps.Async {
+		for i, person := range persons {
+			resp, err := ps.storePending(person)
+			if err != nil {
+				responses[positions[i]].Error = err.Error()
+				continue
+			}
+			responses[positions[i]] = *resp
 		}
+		return responses
 	}
-	if filters.ByAge != "" {
-		validate := strings.Split(filters.ByAge, ":")
-		switch validate[0] {
-		case operatorIs:
-			params = append(params, fmt.Sprintf("AND age = %s", validate[1]))
-			ps.Log.Debug("added filter parametr 'age is'", slog.String("age", validate[1]))
-		case operatorIsnt:
-			params = append(params, fmt.Sprintf("AND age != %s", validate[1]))
-			ps.Log.Debug("added filter parametr 'age is not'", slog.String("age", validate[1]))
-		case operatorLs:
-			params = append(params, fmt.Sprintf("AND age < %s", validate[1]))
-			ps.Log.Debug("added filter parametr 'age less'", slog.String("age", validate[1]))
-		case operatorMt:
-			params = append(params, fmt.Sprintf("AND age > %s", validate[1]))
-			ps.Log.Debug("added filter parametr 'age more'", slog.String("age", validate[1]))
-		default:
-			return nil, fmt.Errorf("invalid age param")
+
+	ctx, recorder := ps.recording(context.Background())
+	errs := ps.enrichBatch(ctx, persons, allAttributes, cacheReadWrite)
+	for i, person := range persons {
+		ps.Log.Debug("get person data from api", slog.Any("person data", person))
+		resp, err := ps.storeEnriched(person, errs[i])
+		if err != nil {
+			responses[positions[i]].Error = err.Error()
+			continue
 		}
+		ps.archive(person, recorder)
+		responses[positions[i]] = *resp
 	}
-	if filters.ByGender != "" {
-		validate := strings.Split(filters.ByGender, ":")
-		switch validate[0] {
-		case operatorIs:
-			params = append(params, fmt.Sprintf("AND gender = '%s'", validate[1]))
-			ps.Log.Debug("added filter parametr 'gender is'", slog.String("gender", validate[1]))
-		case operatorIsnt:
-			params = append(params, fmt.Sprintf("AND gender != '%s'", validate[1]))
-			ps.Log.Debug("added filter parametr 'gender is not'", slog.String("gender", validate[1]))
-		default:
-			return nil, fmt.Errorf("invalid gender param")
+	return responses
+}
+
+// storeEnriched сохраняет обогащённого человека с учётом PartialPolicy и QuotaMode.
+// Ошибки исчерпания квоты обрабатываются по QuotaMode, остальные - по PartialPolicy
+func (ps *PersonService) storeEnriched(person *models.Person, errs map[string]error) (*dto.CreatePersonResponse, error) {
+	quota, other := splitQuotaErrors(errs)
+	if len(quota) > 0 && ps.QuotaMode == QuotaReject {
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, errs[quota[0]])
+	}
+	if len(other) > 0 && ps.PartialPolicy != PartialStore && ps.PartialPolicy != PartialRetry {
+		for _, attribute := range allAttributes {
+			if err, ok := other[attribute]; ok {
+				return nil, err
+			}
 		}
 	}
-	if filters.ByNationality != "" {
-		validate := strings.Split(filters.ByNationality, ":")
-		switch validate[0] {
-		case operatorIs:
-			params = append(params, fmt.Sprintf("AND nationality = '%s'", validate[1]))
-			ps.Log.Debug("added filter parametr 'nationality is'", slog.String("nationality", validate[1]))
-		case operatorIsnt:
-			params = append(params, fmt.Sprintf("AND nationality != '%s'", validate[1]))
-			ps.Log.Debug("added filter parametr 'nationality is not'", slog.String("nationality", validate[1]))
-		default:
-			return nil, fmt.Errorf("invalid nationality param")
+
+	report := makeReport(errs)
+	retry := []string{}
+	for _, attribute := range report.Missing {
+		_, isOther := other[attribute]
+		if (isOther && ps.PartialPolicy == PartialRetry) || (!isOther && ps.QuotaMode != QuotaCache) {
+			retry = append(retry, attribute)
 		}
 	}
-	if filters.ByLimit != 0 {
-		params = append(params, fmt.Sprintf("LIMIT %d", filters.ByLimit))
-		ps.Log.Debug("added filter parametr 'limit'", slog.Int("limit", filters.ByLimit))
+
+	person.EnrichmentStatus = models.EnrichmentDone
+	if len(retry) > 0 {
+		person.EnrichmentStatus = models.EnrichmentPending
 	}
-	if filters.ByOffset != 0 {
-		params = append(params, fmt.Sprintf("OFFSET %d", filters.ByOffset))
-		ps.Log.Debug("added filter parametr 'offset'", slog.Int("offset", filters.ByOffset))
+	id, err := ps.PersonRepo.CreatePerson(person)
+	if err != nil {
+		return nil, err
 	}
-	filter := strings.Join(params, " ")
+	person.ID = id
 
-	return ps.PersonRepo.GetPersonsByParams(filter)
-}
-
-func (ps *PersonService) CreatePerson(person *dto.CreatePerson) (int, error) {
-	for _, r := range person.Name {
-		if !unicode.Is(unicode.Latin, r) {
-			return 0, fmt.Errorf("name must be latin")
+	if len(retry) > 0 {
+		if err := ps.scheduleRetry(id, retry, errs); err != nil {
+			ps.Log.Error("Cannot schedule enrichment retry", slog.Int("id", id), slog.String("error", err.Error()))
+			ps.setEnrichmentStatus(id, models.EnrichmentFailed)
+		} else {
+			report.RetryScheduled = true
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
+	return &dto.CreatePersonResponse{ID: id, Enrichment: &report}, nil
+}
 
-	userData, err := getPersonData(ctx, person)
+// storePending сохраняет человека без обогащения и ставит задачу на обогащение в очередь.
+// Если задачу поставить не удалось, человек уже сохранён, поэтому возвращается его ID со статусом
+// failed, а не ошибка: иначе клиент повторил бы запрос и создал дубликат
+func (ps *PersonService) storePending(person *models.Person) (*dto.CreatePersonResponse, error) {
+	person.EnrichmentStatus = models.EnrichmentPending
+	id, err := ps.PersonRepo.CreatePerson(person)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+
+	if err := ps.Jobs.Enqueue(id, allAttributes, time.Now()); err != nil {
+		ps.Log.Error("Cannot enqueue enrichment", slog.Int("id", id), slog.String("error", err.Error()))
+		ps.setEnrichmentStatus(id, models.EnrichmentFailed)
+		report := dto.EnrichmentReport{Status: models.EnrichmentFailed, Missing: slices.Clone(allAttributes), Errors: map[string]string{}}
+		for _, attribute := range allAttributes {
+			report.Errors[attribute] = fmt.Sprintf("cannot enqueue enrichment: %s", err.Error())
+		}
+		return &dto.CreatePersonResponse{ID: id, Enrichment: &report}, nil
 	}
-	ps.Log.Debug("get person data from api", slog.Any("person data", userData))
 
-	return ps.PersonRepo.CreatePerson(userData)
+	return &dto.CreatePersonResponse{ID: id, Enrichment: &dto.EnrichmentReport{Status: models.EnrichmentPending}}, nil
+}
+
+func (ps *PersonService) setEnrichmentStatus(id int, status string) {
+	if err := ps.PersonRepo.SetEnrichmentStatus(id, status); err != nil {
+		ps.Log.Error("Cannot set enrichment status", slog.Int("id", id), slog.String("status", status), slog.String("error", err.Error()))
+	}
 }
 
 func (ps *PersonService) DeletePersonById(id int) error {
@@ -166,6 +235,9 @@ func (ps *PersonService) UpdatePerson(personDTO *dto.PersonUpdate) error {
 
 	if personDTO.Name != "" {
 		ps.Log.Debug("Name requires updated")
+		if err := validateName(personDTO.Name); err != nil {
+			return err
+		}
 		person.Name = personDTO.Name
 	}
 	if personDTO.Surname != "" {
@@ -176,72 +248,55 @@ func (ps *PersonService) UpdatePerson(personDTO *dto.PersonUpdate) error {
 		ps.Log.Debug("Patronymic requires updated")
 		person.Patronymic = personDTO.Patronymic
 	}
+	ps.transliterate(person)
+	// Исправленные вручную значения отмечаются как manual, и обогащение их больше не трогает.
+	// Оценки провайдера к ним не относятся, поэтому обнуляются
+	manual := models.FieldProvenance{Source: models.SourceManual, UpdatedAt: time.Now()}
 	if personDTO.Age != 0 {
 		ps.Log.Debug("Age requires updated")
-		person.Age = personDTO.Age
+		person.Age = &personDTO.Age
+		person.AgeCount = 0
+		setProvenance(person, attributeAge, manual)
 	}
 	if personDTO.Gender != "" {
 		ps.Log.Debug("Gender requires updated")
-		person.Gender = personDTO.Gender
+		person.Gender = &personDTO.Gender
+		person.GenderProbability = 0
+		person.GenderCount = 0
+		setProvenance(person, attributeGender, manual)
 	}
 	if personDTO.Nationality != "" {
 		ps.Log.Debug("Nationality requires updated")
-		person.Nationality = personDTO.Nationality
+		person.Nationality = &personDTO.Nationality
+		person.NationalityProbability = 0
+		person.Nationalities = []models.PersonNationality{}
+		setProvenance(person, attributeNationality, manual)
 	}
 
-	return ps.PersonRepo.UpdatePerson(person)
-}
-
-func getPersonData(ctx context.Context, createdData *dto.CreatePerson) (*models.Person, error) {
-	person := models.Person{Name: createdData.Name, Surname: createdData.Surname, Patronymic: createdData.Patronymic}
-
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(apiGetAge, person.Name), nil)
-	resp, err := http.DefaultClient.Do(req)
-	if err == nil {
-		var data struct {
-			Age int `json:"age"`
+	reset := []string{}
+	for _, attribute := range personDTO.Reset {
+		if !slices.Contains(allAttributes, attribute) {
+			return fmt.Errorf("cannot reset unknown field: %s", attribute)
 		}
-		json.NewDecoder(resp.Body).Decode(&data)
-		person.Age = data.Age
-	} else {
-		if ctx.Err() == context.DeadlineExceeded {
-			return nil, fmt.Errorf("timeout exceeded while getting age")
+		if isManual(person, attribute) {
+			ps.Log.Debug("Field returned to enrichment", slog.String("field", attribute))
+			delete(person.Provenance, attribute)
+			reset = append(reset, attribute)
 		}
-		return nil, fmt.Errorf("cannot get age: %w", err)
+	}
+	if len(reset) > 0 {
+		person.EnrichmentStatus = models.EnrichmentPending
 	}
 
-	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(apiGetGender, person.Name), nil)
-	resp, err = http.DefaultClient.Do(req)
-	if err == nil {
-		var data struct {
-			Gender string `json:"gender"`
-		}
-		json.NewDecoder(resp.Body).Decode(&data)
-		person.Gender = data.Gender
-	} else {
-		if ctx.Err() == context.DeadlineExceeded {
-			return nil, fmt.Errorf("timeout exceeded while getting gender")
-		}
-		return nil, fmt.Errorf("cannot get gender: %w", err)
+	if err := ps.PersonRepo.UpdatePerson(person); err != nil {
+		return err
 	}
 
-	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(apiGetNationality, person.Name), nil)
-	resp, err = http.DefaultClient.Do(req)
-	if err == nil {
-		var data struct {
-			Country []struct {
-				CountryID string `json:"country_id"`
-			} `json:"country"`
-		}
-		json.NewDecoder(resp.Body).Decode(&data)
-		if len(data.Country) > 0 {
-			person.Nationality = data.Country[0].CountryID
-		}
-	} else {
-		if ctx.Err() == context.DeadlineExceeded {
-			return nil, fmt.Errorf("timeout exceeded while getting 	nationality")
+	// Возвращённые под управление обогащения поля запрашиваются заново в фоне
+	if len(reset) > 0 {
+		if err := ps.Jobs.Enqueue(person.ID, reset, time.Now()); err != nil {
+			return fmt.Errorf("cannot enqueue enrichment: %w", err)
 		}
-		return nil, fmt.Errorf("cannot get nationality: %w", err)
 	}
-	return &person, nil
+	return nil
 }
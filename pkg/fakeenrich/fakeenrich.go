@@ -0,0 +1,234 @@
+// Package fakeenrich эмулирует API agify, genderize и nationalize для разработки и тестов
+// без доступа в интернет. Ответы детерминированы по имени, а задержки, ошибки, 429,
+// пустые результаты и некорректный JSON настраиваются через Options
+package fakeenrich
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	AgifyPath       = "/agify/"
+	GenderizePath   = "/genderize/"
+	NationalizePath = "/nationalize/"
+)
+
+var countries = []string{"RU", "UA", "BY", "KZ", "US", "DE", "FR", "PL", "TR", "GB"}
+
+type Options struct {
+	// Latency - задержка перед каждым ответом
+	Latency time.Duration
+	// ErrorRate - доля запросов (0..1), на которые отвечается 500
+	ErrorRate float64
+	// RateLimitRate - доля запросов (0..1), на которые отвечается 429 с Retry-After
+	RateLimitRate float64
+	// RetryAfter - значение Retry-After в ответах 429
+	RetryAfter time.Duration
+	// RateLimitFirst - сколько первых запросов к каждому API получают 429 с Retry-After
+	RateLimitFirst int
+	// ErrorFirst - сколько первых запросов к каждому API (после RateLimitFirst) получают 500
+	ErrorFirst int
+	// NullRate - доля имён (0..1), для которых возраст, пол и национальность не определяются.
+	// Выбор имён детерминирован: одно и то же имя всегда либо определяется, либо нет
+	NullRate float64
+	// NullNames - имена, для которых значения не определяются
+	NullNames []string
+	// MalformedNames - имена, на запросы с которыми отвечается 200 с телом, не являющимся JSON
+	MalformedNames []string
+	// Quota - дневной лимит запросов на каждый API, после которого отвечается 429; 0 - без лимита
+	Quota int
+}
+
+// Handler отвечает как agify, genderize и nationalize по путям AgifyPath, GenderizePath
+// и NationalizePath. Поддерживаются name, name[] и country_id
+type Handler struct {
+	opts Options
+	mux  *http.ServeMux
+
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+func NewHandler(opts Options) *Handler {
+	h := &Handler{opts: opts, mux: http.NewServeMux(), requests: map[string]int{}}
+	h.mux.Handle(AgifyPath, h.api(AgifyPath, age))
+	h.mux.Handle(GenderizePath, h.api(GenderizePath, gender))
+	h.mux.Handle(NationalizePath, h.api(NationalizePath, nationality))
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// Requests возвращает количество запросов к API по пути (например, AgifyPath)
+func (h *Handler) Requests(path string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.requests[path]
+}
+
+func (h *Handler) api(path string, answer func(name string, known bool) map[string]any) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.mu.Lock()
+		h.requests[path]++
+		used := h.requests[path]
+		h.mu.Unlock()
+
+		if h.opts.Latency > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(h.opts.Latency):
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if h.opts.Quota > 0 {
+			w.Header().Set("X-Rate-Limit-Limit", strconv.Itoa(h.opts.Quota))
+			w.Header().Set("X-Rate-Limit-Remaining", strconv.Itoa(max(h.opts.Quota-used, 0)))
+			w.Header().Set("X-Rate-Limit-Reset", strconv.Itoa(secondsToMidnight()))
+		}
+		switch {
+		case h.opts.Quota > 0 && used > h.opts.Quota:
+			writeError(w, http.StatusTooManyRequests, "Request limit reached")
+			return
+		case used <= h.opts.RateLimitFirst || chance(h.opts.RateLimitRate):
+			w.Header().Set("Retry-After", strconv.Itoa(int(h.opts.RetryAfter.Seconds())))
+			writeError(w, http.StatusTooManyRequests, "Request limit too low to process request")
+			return
+		case used <= h.opts.RateLimitFirst+h.opts.ErrorFirst || chance(h.opts.ErrorRate):
+			writeError(w, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+
+		query := r.URL.Query()
+		if names := slices.Concat(query["name[]"], query["name"]); slices.ContainsFunc(names, h.malformed) {
+			io.WriteString(w, `{"name": `)
+			return
+		}
+		respond := func(name string) map[string]any {
+			resp := answer(name, h.known(name))
+			resp["name"] = name
+			if country := query.Get("country_id"); country != "" && path != NationalizePath {
+				resp["country_id"] = country
+			}
+			return resp
+		}
+
+		if names, ok := query["name[]"]; ok {
+			resp := make([]map[string]any, len(names))
+			for i, name := range names {
+				resp[i] = respond(name)
+			}
+			json.NewEncoder(w).Encode(resp)
+			return
+		}
+		if !query.Has("name") {
+			writeError(w, http.StatusUnprocessableEntity, "Missing 'name' parameter")
+			return
+		}
+		json.NewEncoder(w).Encode(respond(query.Get("name")))
+	})
+}
+
+func (h *Handler) malformed(name string) bool {
+	return slices.ContainsFunc(h.opts.MalformedNames, func(n string) bool { return strings.EqualFold(n, name) })
+}
+
+func (h *Handler) known(name string) bool {
+	for _, n := range h.opts.NullNames {
+		if strings.EqualFold(n, name) {
+			return false
+		}
+	}
+	return float64(hash(name, "null")%1000) >= h.opts.NullRate*1000
+}
+
+func age(name string, known bool) map[string]any {
+	if !known {
+		return map[string]any{"age": nil, "count": 0}
+	}
+	return map[string]any{"age": 18 + hash(name, "age")%63, "count": 100 + hash(name, "count")%10000}
+}
+
+// gender считает женскими имена на -a и -ya, как в большинстве русских имён
+func gender(name string, known bool) map[string]any {
+	if !known {
+		return map[string]any{"gender": nil, "probability": 0.0, "count": 0}
+	}
+	g := "male"
+	if strings.HasSuffix(strings.ToLower(name), "a") {
+		g = "female"
+	}
+	return map[string]any{"gender": g, "probability": 0.75 + float64(hash(name, "probability")%25)/100, "count": 100 + hash(name, "count")%10000}
+}
+
+func nationality(name string, known bool) map[string]any {
+	if !known {
+		return map[string]any{"country": []any{}}
+	}
+	first := hash(name, "country") % uint32(len(countries))
+	probability := 0.4 + float64(hash(name, "probability")%40)/100
+	result := []map[string]any{}
+	for i := range uint32(3) {
+		result = append(result, map[string]any{"country_id": countries[(first+i)%uint32(len(countries))], "probability": probability})
+		probability /= 3
+	}
+	return map[string]any{"country": result}
+}
+
+func hash(name, salt string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(name) + "\x00" + salt))
+	return h.Sum32()
+}
+
+func chance(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
+
+func secondsToMidnight() int {
+	now := time.Now().UTC()
+	midnight := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+	return int(midnight.Sub(now).Seconds())
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// Server - запущенный httptest-сервер с Handler для тестов
+type Server struct {
+	*httptest.Server
+	Handler *Handler
+}
+
+// NewServer запускает сервер; его нужно остановить через Close
+func NewServer(opts Options) *Server {
+	h := NewHandler(opts)
+	return &Server{Server: httptest.NewServer(h), Handler: h}
+}
+
+func (s *Server) AgifyURL() string {
+	return s.URL + AgifyPath
+}
+
+func (s *Server) GenderizeURL() string {
+	return s.URL + GenderizePath
+}
+
+func (s *Server) NationalizeURL() string {
+	return s.URL + NationalizePath
+}
@@ -0,0 +1,76 @@
+package lru
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := New[string, int](2)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("a should be cached")
+	}
+	c.Add("c", 3)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("b should be evicted as least recently used")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if got, ok := c.Get(key); !ok || got != want {
+			t.Errorf("Get(%q) = %d, %v; want %d, true", key, got, ok, want)
+		}
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d; want 2", c.Len())
+	}
+}
+
+func TestCacheAddUpdatesExisting(t *testing.T) {
+	c := New[string, int](2)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Add("a", 10)
+	c.Add("c", 3)
+
+	if got, ok := c.Get("a"); !ok || got != 10 {
+		t.Errorf("Get(a) = %d, %v; want 10, true", got, ok)
+	}
+	if _, ok := c.Get("b"); ok {
+		t.Error("b should be evicted after a was updated")
+	}
+}
+
+func TestCacheUnbounded(t *testing.T) {
+	c := New[int, int](0)
+	for i := range 100 {
+		c.Add(i, i)
+	}
+	if c.Len() != 100 {
+		t.Errorf("Len() = %d; want 100", c.Len())
+	}
+}
+
+func TestCacheRemove(t *testing.T) {
+	c := New[string, int](10)
+	for _, key := range []string{"ivan", "ivan:RU", "ivan:UA", "anna"} {
+		c.Add(key, 1)
+	}
+
+	c.Remove("anna")
+	if _, ok := c.Get("anna"); ok {
+		t.Error("anna should be removed")
+	}
+
+	c.RemoveFunc(func(key string) bool { return key == "ivan" || strings.HasPrefix(key, "ivan:") })
+	if c.Len() != 0 {
+		t.Errorf("Len() = %d after RemoveFunc; want 0", c.Len())
+	}
+
+	c.Add("a", 1)
+	c.Purge()
+	if _, ok := c.Get("a"); ok || c.Len() != 0 {
+		t.Error("Purge should remove everything")
+	}
+}
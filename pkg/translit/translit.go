@@ -0,0 +1,95 @@
+package translit
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Standard - система транслитерации кириллицы латиницей
+type Standard string
+
+const (
+	// GOST779 - ГОСТ 7.79-2000, система Б (Дмитрий -> Dmitrij, Щукин -> Shhukin)
+	GOST779 Standard = "gost779"
+	// ICAO - ICAO Doc 9303, как в загранпаспортах РФ (Дмитрий -> Dmitrii, Щукин -> Shchukin)
+	ICAO Standard = "icao"
+)
+
+var tables = map[Standard]map[rune]string{
+	GOST779: {
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh", 'з': "z", 'и': "i",
+		'й': "j", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+		'у': "u", 'ф': "f", 'х': "x", 'ц': "cz", 'ч': "ch", 'ш': "sh", 'щ': "shh", 'ъ': "``", 'ы': "y`", 'ь': "`",
+		'э': "e`", 'ю': "yu", 'я': "ya",
+		'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g`", 'ў': "u`",
+	},
+	ICAO: {
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z", 'и': "i",
+		'й': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+		'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "ie", 'ы': "y", 'ь': "",
+		'э': "e", 'ю': "iu", 'я': "ia",
+		'і': "i", 'ї': "i", 'є': "ie", 'ґ': "g", 'ў': "u",
+	},
+}
+
+func Parse(s string) (Standard, error) {
+	switch std := Standard(strings.ToLower(s)); std {
+	case GOST779, ICAO:
+		return std, nil
+	case "":
+		return ICAO, nil
+	default:
+		return "", fmt.Errorf("unknown transliteration standard: %s", s)
+	}
+}
+
+// Transliterate заменяет кириллические буквы латинскими по стандарту std. Остальные символы,
+// в том числе буквы других алфавитов, остаются без изменений
+func Transliterate(s string, std Standard) string {
+	table, ok := tables[std]
+	if !ok {
+		table = tables[ICAO]
+	}
+
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		lower := unicode.ToLower(r)
+		latin, ok := table[lower]
+		if !ok {
+			b.WriteRune(r)
+			continue
+		}
+		// По ГОСТ ц перед е, и, ы, й передаётся как c
+		if std == GOST779 && lower == 'ц' && i+1 < len(runes) && strings.ContainsRune("еиыйії", unicode.ToLower(runes[i+1])) {
+			latin = "c"
+		}
+		if unicode.IsUpper(r) {
+			latin = upper(latin, runes, i)
+		}
+		b.WriteString(latin)
+	}
+	return b.String()
+}
+
+// Plain убирает из транслитерации обратные апострофы, которыми ГОСТ 7.79 передаёт ъ, ь, ы и э
+// (Natal`ya -> Natalya). Такая форма нужна сервисам, не знающим о транслитерации
+func Plain(s string) string {
+	return strings.ReplaceAll(s, "`", "")
+}
+
+// upper переводит в верхний регистр первую букву замены, а если соседние буквы тоже
+// заглавные (ЩУКИН) - всю замену
+func upper(latin string, runes []rune, i int) string {
+	neighbourUpper := (i+1 < len(runes) && unicode.IsUpper(runes[i+1])) || (i > 0 && unicode.IsUpper(runes[i-1]))
+	if neighbourUpper {
+		return strings.ToUpper(latin)
+	}
+	for j, r := range latin {
+		if unicode.IsLetter(r) {
+			return latin[:j] + string(unicode.ToUpper(r)) + latin[j+len(string(r)):]
+		}
+	}
+	return latin
+}
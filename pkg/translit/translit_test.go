@@ -0,0 +1,83 @@
+package translit
+
+import "testing"
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		std  Standard
+		want string
+	}{
+		{"icao name", "Дмитрий", ICAO, "Dmitrii"},
+		{"gost name", "Дмитрий", GOST779, "Dmitrij"},
+		{"icao hard sign", "Подъячев", ICAO, "Podieiachev"},
+		{"icao soft sign", "Наталья", ICAO, "Natalia"},
+		{"icao short i", "Андрей", ICAO, "Andrei"},
+		{"icao yo", "Пётр", ICAO, "Petr"},
+		{"icao ts", "Цой", ICAO, "Tsoi"},
+		{"gost cz before a", "Царёв", GOST779, "Czaryov"},
+		{"gost c before e", "Цезарь", GOST779, "Cezar`"},
+		{"gost c before i", "Цилинский", GOST779, "Cilinskij"},
+		{"gost c before y", "Цыганов", GOST779, "Cy`ganov"},
+		{"gost c before short i", "Гуцйо", GOST779, "Gucjo"},
+		{"gost cz at the end", "Кузнец", GOST779, "Kuznecz"},
+		{"gost soft and hard signs", "Подъячев Наталья", GOST779, "Pod``yachev Natal`ya"},
+		{"icao all caps", "ЩУКИН", ICAO, "SHCHUKIN"},
+		{"gost all caps", "ЩУКИН", GOST779, "SHHUKIN"},
+		{"icao capitalized", "Щукин", ICAO, "Shchukin"},
+		{"single letter", "Я", ICAO, "Ia"},
+		{"single letter gost", "Ю", GOST779, "Yu"},
+		{"single lowercase letter", "я", ICAO, "ia"},
+		{"latin passthrough", "John", ICAO, "John"},
+		{"mixed script", "Иван Smith", ICAO, "Ivan Smith"},
+		{"mixed within word", "Jÿрий", ICAO, "Jÿrii"},
+		{"ukrainian letters", "Їжак Євген", ICAO, "Izhak Ievgen"},
+		{"unknown standard falls back to icao", "Щукин", Standard("x"), "Shchukin"},
+		{"empty", "", ICAO, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Transliterate(tt.in, tt.std); got != tt.want {
+				t.Errorf("Transliterate(%q, %s) = %q; want %q", tt.in, tt.std, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlain(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Natal`ya", "Natalya"},
+		{"Pod``yachev", "Podyachev"},
+		{"John", "John"},
+		{"Ivan Smith", "Ivan Smith"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Plain(tt.in); got != tt.want {
+			t.Errorf("Plain(%q) = %q; want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Standard
+		wantErr bool
+	}{
+		{"", ICAO, false},
+		{"icao", ICAO, false},
+		{"GOST779", GOST779, false},
+		{"iso9", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}